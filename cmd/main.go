@@ -40,7 +40,8 @@ func run() error {
 	}
 
 	// Create Azure DevOps client
-	client := azuredevops.NewClient(cfg.Organization, pat)
+	collectionURL := azuredevops.CollectionURL(cfg.ServerURL, cfg.Collection, cfg.Organization)
+	client := azuredevops.NewClient(collectionURL, cfg.APIVersion, pat)
 
 	// Create and run the UI
 	model := ui.NewModel(cfg, client)
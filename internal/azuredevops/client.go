@@ -1,44 +1,147 @@
 package azuredevops
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
 	"strings"
+	"sync"
 	"time"
-
-	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
-const (
-	apiVersion = "7.1"
-	baseURL    = "https://dev.azure.com"
-)
+// errInvalidRequest is returned for requests that cannot be built, such as a
+// malformed URL. Retrying them can never succeed.
+var errInvalidRequest = errors.New("failed to create request")
 
 // Client represents an Azure DevOps API client
 type Client struct {
-	organization string
-	pat          string
-	httpClient   *http.Client
+	urls           URLBuilder
+	pat            string
+	httpClient     *http.Client
+	requestTimeout time.Duration
+	retry          RetryPolicy
+	throttleMu     sync.Mutex
+	throttle       ThrottleState
+	definitionsMu  sync.Mutex
+	definitions    map[string]Definition // Resolved pipeline definitions by project and name or ID
+	identityMu     sync.Mutex
+	identity       *Identity // Authenticated user, looked up on first use
 }
 
-// NewClient creates a new Azure DevOps client
-func NewClient(organization, pat string) *Client {
+// NewClient creates a new Azure DevOps client for the given collection URL
+// (see CollectionURL) and REST API version
+func NewClient(collectionURL, apiVersion, pat string) *Client {
 	return &Client{
-		organization: organization,
-		pat:          pat,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+		urls:           NewURLBuilder(collectionURL, apiVersion),
+		pat:            pat,
+		httpClient:     &http.Client{},
+		requestTimeout: 30 * time.Second,
+		retry:          DefaultRetryPolicy(),
+		definitions:    make(map[string]Definition),
+		throttle: ThrottleState{
+			Limit:     -1,
+			Remaining: -1,
 		},
 	}
 }
 
 // doRequest performs an authenticated HTTP request
-func (c *Client) doRequest(url string) ([]byte, error) {
-	req, err := http.NewRequest("GET", url, nil)
+func (c *Client) doRequest(ctx context.Context, url string) ([]byte, error) {
+	body, _, err := c.doRequestWithHeader(ctx, url)
+	return body, err
+}
+
+// doRequestWithHeader performs an authenticated HTTP request and also returns
+// the response headers, which carry paging information
+func (c *Client) doRequestWithHeader(ctx context.Context, url string) ([]byte, http.Header, error) {
+	return c.request(ctx, http.MethodGet, url, nil)
+}
+
+// doJSON performs an authenticated write request with body encoded as JSON and
+// decodes the response into result unless it is nil
+func (c *Client) doJSON(ctx context.Context, method, url string, body, result any) error {
+	payload, err := json.Marshal(body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to encode request body: %w", err)
+	}
+
+	response, _, err := c.request(ctx, method, url, payload)
+	if err != nil {
+		return err
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(response, result); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// request performs an authenticated HTTP request with an optional JSON body.
+// Transient failures and throttling responses are retried according to the
+// client's retry policy, except that writes are only retried when throttled
+// because they may have been applied before failing.
+func (c *Client) request(ctx context.Context, method, url string, payload []byte) ([]byte, http.Header, error) {
+	for attempt := 0; ; attempt++ {
+		body, header, status, err := c.send(ctx, method, url, payload)
+		if header != nil {
+			c.recordRateLimit(header)
+		}
+
+		// Never retry once the caller has given up
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, ctxErr
+		}
+
+		retryable := (err != nil && !errors.Is(err, errInvalidRequest)) || isRetryableStatus(status)
+		if method != http.MethodGet {
+			retryable = status == http.StatusTooManyRequests
+		}
+		if retryable && attempt < c.retry.MaxRetries {
+			timer := time.NewTimer(c.retryDelay(attempt, header))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, nil, ctx.Err()
+			case <-timer.C:
+			}
+			continue
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// 203 is the sign-in page served for an invalid token, not a success
+		if status != http.StatusOK && status != http.StatusCreated && status != http.StatusNoContent {
+			return nil, nil, fmt.Errorf("API request failed with status %d: %s", status, string(body))
+		}
+
+		return body, header, nil
+	}
+}
+
+// send performs a single authenticated HTTP request attempt, bounded by the
+// client's per-request timeout
+func (c *Client) send(ctx context.Context, method, url string, payload []byte) ([]byte, http.Header, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.requestTimeout)
+	defer cancel()
+
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("%w: %v", errInvalidRequest, err)
 	}
 
 	// Set basic authentication with PAT
@@ -47,38 +150,56 @@ func (c *Client) doRequest(url string) ([]byte, error) {
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, nil, 0, fmt.Errorf("failed to execute request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, resp.Header, resp.StatusCode, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
+	return body, resp.Header, resp.StatusCode, nil
+}
 
-	return body, nil
+// URLs returns the URL builder for the client's collection
+func (c *Client) URLs() URLBuilder {
+	return c.urls
 }
 
 // PullRequest represents a pull request
 type PullRequest struct {
-	ID           int       `json:"pullRequestId"`
-	Title        string    `json:"title"`
-	Description  string    `json:"description"`
-	Status       string    `json:"status"`
-	CreatedBy    User      `json:"createdBy"`
-	CreationDate time.Time `json:"creationDate"`
-	Repository   Repository `json:"repository"`
-	SourceRefName string   `json:"sourceRefName"`
-	TargetRefName string   `json:"targetRefName"`
-	IsDraft      bool      `json:"isDraft"`
+	ID                    int                `json:"pullRequestId"`
+	Title                 string             `json:"title"`
+	Description           string             `json:"description"`
+	Status                string             `json:"status"`
+	CreatedBy             User               `json:"createdBy"`
+	CreationDate          time.Time          `json:"creationDate"`
+	Repository            Repository         `json:"repository"`
+	SourceRefName         string             `json:"sourceRefName"`
+	TargetRefName         string             `json:"targetRefName"`
+	IsDraft               bool               `json:"isDraft"`
+	Labels                []Label            `json:"labels"`
+	Reviewers             []Reviewer         `json:"reviewers"`
+	AutoCompleteSetBy     *User              `json:"autoCompleteSetBy"` // Set when auto-complete is enabled
+	CompletionOptions     *CompletionOptions `json:"completionOptions"`
+	LastMergeSourceCommit *CommitRef         `json:"lastMergeSourceCommit"` // Source commit the PR was last merged at
+	MergeStatus           string             `json:"mergeStatus"`
+	Checks                *PRChecks          `json:"-"` // Loaded separately, nil when not loaded
+	ChecksErr             error              `json:"-"` // Why Checks could not be loaded
+	VoteReset             bool               `json:"-"` // The authenticated user's vote was reset by a push, loaded separately
+}
+
+// Label represents a label (tag) on a pull request
+type Label struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
 }
 
 // User represents a user
 type User struct {
+	ID          string `json:"id"`
 	DisplayName string `json:"displayName"`
 	UniqueName  string `json:"uniqueName"`
 }
@@ -91,45 +212,89 @@ type Repository struct {
 
 // Project represents a project
 type Project struct {
+	ID   string `json:"id"`
 	Name string `json:"name"`
 }
 
 // PullRequestsResponse represents the API response for pull requests
 type PullRequestsResponse struct {
 	Value []PullRequest `json:"value"`
-	Count int          `json:"count"`
+	Count int           `json:"count"`
+}
+
+// PRSearchCriteria narrows down the active pull requests returned by the server.
+// Empty fields don't filter.
+type PRSearchCriteria struct {
+	TargetRefName string // Full ref name, e.g. refs/heads/main
+	CreatorID     string
+	ReviewerID    string
+}
+
+// query returns the criteria as query parameters, including the active status
+func (c PRSearchCriteria) query() string {
+	query := neturl.Values{}
+	query.Set("searchCriteria.status", "active")
+	if c.TargetRefName != "" {
+		query.Set("searchCriteria.targetRefName", c.TargetRefName)
+	}
+	if c.CreatorID != "" {
+		query.Set("searchCriteria.creatorId", c.CreatorID)
+	}
+	if c.ReviewerID != "" {
+		query.Set("searchCriteria.reviewerId", c.ReviewerID)
+	}
+	return query.Encode()
 }
 
 // GetPullRequests fetches active pull requests for a repository
-func (c *Client) GetPullRequests(project, repository string) ([]PullRequest, error) {
-	url := fmt.Sprintf("%s/%s/%s/_apis/git/repositories/%s/pullrequests?searchCriteria.status=active&api-version=%s",
-		baseURL, c.organization, project, repository, apiVersion)
+// A limit of 0 or less fetches every active pull request.
+func (c *Client) GetPullRequests(ctx context.Context, project, repository string, criteria PRSearchCriteria, limit int) ([]PullRequest, error) {
+	url := c.urls.API(project, repositoryPath(repository, "pullrequests?"+criteria.query()))
 
-	body, err := c.doRequest(url)
+	pullRequests, err := getPaged[PullRequest](ctx, c, url, limit)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to get pull requests: %w", err)
 	}
 
-	var response PullRequestsResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse pull requests response: %w", err)
+	return pullRequests, nil
+}
+
+// GetProjectPullRequests fetches active pull requests for every repository in a project
+// A limit of 0 or less fetches every active pull request.
+func (c *Client) GetProjectPullRequests(ctx context.Context, project string, criteria PRSearchCriteria, limit int) ([]PullRequest, error) {
+	url := c.urls.API(project, "git/pullrequests?"+criteria.query())
+
+	pullRequests, err := getPaged[PullRequest](ctx, c, url, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pull requests of project %s: %w", project, err)
 	}
 
-	return response.Value, nil
+	return pullRequests, nil
+}
+
+// GetProjects fetches every project in the organization or collection
+func (c *Client) GetProjects(ctx context.Context) ([]Project, error) {
+	projects, err := getPaged[Project](ctx, c, c.urls.CollectionAPI("projects"), 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get projects: %w", err)
+	}
+
+	return projects, nil
 }
 
 // Build represents a pipeline build/run
 type Build struct {
-	ID            int       `json:"id"`
-	BuildNumber   string    `json:"buildNumber"`
-	Status        string    `json:"status"`
-	Result        string    `json:"result"`
-	QueueTime     time.Time `json:"queueTime"`
-	StartTime     time.Time `json:"startTime"`
-	FinishTime    time.Time `json:"finishTime"`
-	SourceBranch  string    `json:"sourceBranch"`
-	Definition    Definition `json:"definition"`
-	RequestedFor  User      `json:"requestedFor"`
+	ID           int        `json:"id"`
+	BuildNumber  string     `json:"buildNumber"`
+	Status       string     `json:"status"`
+	Result       string     `json:"result"`
+	QueueTime    time.Time  `json:"queueTime"`
+	StartTime    time.Time  `json:"startTime"`
+	FinishTime   time.Time  `json:"finishTime"`
+	SourceBranch string     `json:"sourceBranch"`
+	Definition   Definition `json:"definition"`
+	Project      Project    `json:"project"`
+	RequestedFor User       `json:"requestedFor"`
 }
 
 // Definition represents a pipeline definition
@@ -144,65 +309,99 @@ type BuildsResponse struct {
 	Count int     `json:"count"`
 }
 
-// GetBuilds fetches recent builds for a pipeline
-// Either pipelineName or definitionID can be provided. If definitionID is provided (> 0), it will be used directly.
-func (c *Client) GetBuilds(project, pipelineName string, definitionID int) ([]Build, error) {
-	var definition Definition
-	var err error
+// GetBuild fetches a single build
+func (c *Client) GetBuild(ctx context.Context, project string, buildID int) (Build, error) {
+	url := c.urls.API(project, fmt.Sprintf("build/builds/%d", buildID))
 
-	if definitionID > 0 {
-		// Use the provided definition ID directly
-		definition, err = c.getDefinitionByID(project, definitionID)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		// Search for pipeline by name
-		definition, err = c.getPipelineDefinition(project, pipelineName)
-		if err != nil {
-			return nil, err
-		}
+	body, err := c.doRequest(ctx, url)
+	if err != nil {
+		return Build{}, fmt.Errorf("failed to get build: %w", err)
+	}
+
+	var build Build
+	if err := json.Unmarshal(body, &build); err != nil {
+		return Build{}, fmt.Errorf("failed to parse build: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/%s/%s/_apis/build/builds?definitions=%d&statusFilter=all&queryOrder=queueTimeDescending&$top=10&api-version=%s",
-		baseURL, c.organization, project, definition.ID, apiVersion)
+	return build, nil
+}
 
-	body, err := c.doRequest(url)
+// GetBuilds fetches recent builds for a pipeline
+// Either pipelineName or definitionID can be provided. If definitionID is provided (> 0), it will be used directly.
+// A limit of 0 or less fetches every build of the pipeline.
+func (c *Client) GetBuilds(ctx context.Context, project, pipelineName string, definitionID int, limit int) ([]Build, error) {
+	definition, err := c.resolveDefinition(ctx, project, pipelineName, definitionID)
 	if err != nil {
 		return nil, err
 	}
 
-	var response BuildsResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse builds response: %w", err)
+	url := c.urls.API(project, fmt.Sprintf("build/builds?definitions=%d&statusFilter=all&queryOrder=queueTimeDescending",
+		definition.ID))
+
+	builds, err := getPaged[Build](ctx, c, url, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get builds: %w", err)
 	}
 
 	// Ensure all builds have the definition name populated
 	// The API response may not include the full definition details
-	for i := range response.Value {
-		if response.Value[i].Definition.Name == "" {
-			response.Value[i].Definition.Name = definition.Name
+	for i := range builds {
+		if builds[i].Definition.Name == "" {
+			builds[i].Definition.Name = definition.Name
 		}
-		if response.Value[i].Definition.ID == 0 {
-			response.Value[i].Definition.ID = definition.ID
+		if builds[i].Definition.ID == 0 {
+			builds[i].Definition.ID = definition.ID
 		}
 	}
 
-	return response.Value, nil
+	return builds, nil
 }
 
 // DefinitionsResponse represents the API response for pipeline definitions
 type DefinitionsResponse struct {
 	Value []Definition `json:"value"`
-	Count int         `json:"count"`
+	Count int          `json:"count"`
+}
+
+// resolveDefinition returns the pipeline definition for a name or ID, using the
+// client's cache so each definition is only looked up once
+func (c *Client) resolveDefinition(ctx context.Context, project, pipelineName string, definitionID int) (Definition, error) {
+	key := fmt.Sprintf("%s/%s", project, pipelineName)
+	if definitionID > 0 {
+		key = fmt.Sprintf("%s/#%d", project, definitionID)
+	}
+
+	c.definitionsMu.Lock()
+	definition, ok := c.definitions[key]
+	c.definitionsMu.Unlock()
+	if ok {
+		return definition, nil
+	}
+
+	var err error
+	if definitionID > 0 {
+		// Use the provided definition ID directly
+		definition, err = c.getDefinitionByID(ctx, project, definitionID)
+	} else {
+		// Search for pipeline by name
+		definition, err = c.getPipelineDefinition(ctx, project, pipelineName)
+	}
+	if err != nil {
+		return Definition{}, err
+	}
+
+	c.definitionsMu.Lock()
+	c.definitions[key] = definition
+	c.definitionsMu.Unlock()
+
+	return definition, nil
 }
 
 // getDefinitionByID gets the pipeline definition by its ID
-func (c *Client) getDefinitionByID(project string, definitionID int) (Definition, error) {
-	url := fmt.Sprintf("%s/%s/%s/_apis/build/definitions/%d?api-version=%s",
-		baseURL, c.organization, project, definitionID, apiVersion)
+func (c *Client) getDefinitionByID(ctx context.Context, project string, definitionID int) (Definition, error) {
+	url := c.urls.API(project, fmt.Sprintf("build/definitions/%d", definitionID))
 
-	body, err := c.doRequest(url)
+	body, err := c.doRequest(ctx, url)
 	if err != nil {
 		return Definition{}, fmt.Errorf("failed to get definition %d: %w", definitionID, err)
 	}
@@ -216,11 +415,10 @@ func (c *Client) getDefinitionByID(project string, definitionID int) (Definition
 }
 
 // getPipelineDefinition gets the pipeline definition (ID and Name) by name
-func (c *Client) getPipelineDefinition(project, pipelineName string) (Definition, error) {
-	url := fmt.Sprintf("%s/%s/%s/_apis/build/definitions?name=%s&api-version=%s",
-		baseURL, c.organization, project, pipelineName, apiVersion)
+func (c *Client) getPipelineDefinition(ctx context.Context, project, pipelineName string) (Definition, error) {
+	url := c.urls.API(project, fmt.Sprintf("build/definitions?name=%s", neturl.QueryEscape(pipelineName)))
 
-	body, err := c.doRequest(url)
+	body, err := c.doRequest(ctx, url)
 	if err != nil {
 		return Definition{}, err
 	}
@@ -247,82 +445,108 @@ type PRIterationsResponse struct {
 	Value []PRIteration `json:"value"`
 }
 
+// Change types reported for pull request changes. A change may combine
+// several of them, e.g. "edit, rename".
+const (
+	ChangeTypeAdd    = "add"
+	ChangeTypeEdit   = "edit"
+	ChangeTypeDelete = "delete"
+	ChangeTypeRename = "rename"
+)
+
 // PRChange represents a file change in a pull request
 type PRChange struct {
-	ChangeType string     `json:"changeType"`
-	Item       PRItem     `json:"item"`
+	ChangeTrackingID int    `json:"changeTrackingId"` // Identifies the file across iterations
+	ChangeType       string `json:"changeType"`
+	Item             PRItem `json:"item"`
+	OriginalPath     string `json:"originalPath"` // Previous path of a renamed file
+}
+
+// HasChangeType reports whether the change includes the given change type
+func (c PRChange) HasChangeType(changeType string) bool {
+	for _, t := range strings.Split(c.ChangeType, ",") {
+		if strings.EqualFold(strings.TrimSpace(t), changeType) {
+			return true
+		}
+	}
+	return false
 }
 
 // PRItem represents a file item in a pull request
 type PRItem struct {
-	Path string `json:"path"`
+	Path          string `json:"path"`
+	IsFolder      bool   `json:"isFolder"`
+	GitObjectType string `json:"gitObjectType"`
 }
 
-// PRChangesResponse represents the API response for PR changes
+// PRChangesResponse represents the API response for PR iteration changes
 type PRChangesResponse struct {
-	Changes []PRChange `json:"changes"`
+	ChangeEntries []PRChange `json:"changeEntries"`
+	NextSkip      int        `json:"nextSkip"`
+	NextTop       int        `json:"nextTop"`
 }
 
-// GetPRFiles fetches the list of changed files in a pull request
-func (c *Client) GetPRFiles(project, repository string, prID int) ([]string, error) {
-	// Get all commits in the PR
-	commitsURL := fmt.Sprintf("%s/%s/%s/_apis/git/repositories/%s/pullRequests/%d/commits?api-version=%s",
-		baseURL, c.organization, project, repository, prID, apiVersion)
+// GetLatestIteration fetches the ID of the latest iteration of a pull request,
+// i.e. its most recent push
+func (c *Client) GetLatestIteration(ctx context.Context, project, repository string, prID int) (int, error) {
+	iterationsURL := c.urls.API(project, repositoryPath(repository, fmt.Sprintf("pullRequests/%d/iterations", prID)))
 
-	body, err := c.doRequest(commitsURL)
+	body, err := c.doRequest(ctx, iterationsURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get PR commits: %w", err)
+		return 0, fmt.Errorf("failed to get PR iterations: %w", err)
 	}
 
-	var commitsResponse struct {
-		Value []struct {
-			CommitID string `json:"commitId"`
-		} `json:"value"`
+	var iterations PRIterationsResponse
+	if err := json.Unmarshal(body, &iterations); err != nil {
+		return 0, fmt.Errorf("failed to parse iterations response: %w", err)
 	}
 
-	if err := json.Unmarshal(body, &commitsResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse commits response: %w", err)
+	if len(iterations.Value) == 0 {
+		return 0, fmt.Errorf("no iterations found in this PR")
 	}
 
-	if len(commitsResponse.Value) == 0 {
-		return nil, fmt.Errorf("no commits found in this PR")
+	latest := iterations.Value[0].ID
+	for _, iteration := range iterations.Value {
+		if iteration.ID > latest {
+			latest = iteration.ID
+		}
 	}
 
-	// Collect all changed files from all commits
-	seenFiles := make(map[string]bool)
-	files := make([]string, 0)
+	return latest, nil
+}
 
-	for _, commit := range commitsResponse.Value {
-		// Get changes for each commit
-		changesURL := fmt.Sprintf("%s/%s/%s/_apis/git/repositories/%s/commits/%s/changes?api-version=%s",
-			baseURL, c.organization, project, repository, commit.CommitID, apiVersion)
+// GetPRFiles fetches the net file changes of a pull request, comparing the
+// given iteration (see GetLatestIteration) with the target branch
+func (c *Client) GetPRFiles(ctx context.Context, project, repository string, prID, iteration int) ([]PRChange, error) {
 
-		body, err := c.doRequest(changesURL)
+	// The changes endpoint pages with $top/$skip and reports the next page in the body
+	files := make([]PRChange, 0)
+	skip := 0
+	for {
+		changesURL := c.urls.API(project, repositoryPath(repository, fmt.Sprintf("pullRequests/%d/iterations/%d/changes?$compareTo=0&$top=%d&$skip=%d",
+			prID, iteration, defaultPageSize, skip)))
+
+		body, err := c.doRequest(ctx, changesURL)
 		if err != nil {
-			// Skip commits that fail - might be merge commits or have issues
-			continue
+			return nil, fmt.Errorf("failed to get PR changes: %w", err)
 		}
 
-		var changesResponse struct {
-			Changes []struct {
-				Item struct {
-					Path string `json:"path"`
-				} `json:"item"`
-			} `json:"changes"`
+		var changes PRChangesResponse
+		if err := json.Unmarshal(body, &changes); err != nil {
+			return nil, fmt.Errorf("failed to parse changes response: %w", err)
 		}
 
-		if err := json.Unmarshal(body, &changesResponse); err != nil {
-			continue
+		for _, change := range changes.ChangeEntries {
+			if change.Item.IsFolder || change.Item.GitObjectType == "tree" || change.Item.Path == "" {
+				continue
+			}
+			files = append(files, change)
 		}
 
-		// Add unique file paths
-		for _, change := range changesResponse.Changes {
-			path := change.Item.Path
-			if path != "" && !seenFiles[path] && path != "/" {
-				files = append(files, path)
-				seenFiles[path] = true
-			}
+		if changes.NextTop == 0 || changes.NextSkip <= skip {
+			break
 		}
+		skip = changes.NextSkip
 	}
 
 	if len(files) == 0 {
@@ -332,21 +556,19 @@ func (c *Client) GetPRFiles(project, repository string, prID int) ([]string, err
 	return files, nil
 }
 
-// PRDiff represents a diff for a file
-type PRDiff struct {
-	Path string
-	Diff string
+// PRCommits holds the commits a pull request diff is computed between
+type PRCommits struct {
+	SourceCommitID string // Last merge source commit, the PR version
+	TargetCommitID string // Last merge target commit, the base version
 }
 
-// GetPRFileDiff fetches the diff for a specific file in a pull request
-func (c *Client) GetPRFileDiff(project, repository string, prID int, filePath string) (string, error) {
-	// Get the PR details to get source and target commits
-	prURL := fmt.Sprintf("%s/%s/%s/_apis/git/repositories/%s/pullRequests/%d?api-version=%s",
-		baseURL, c.organization, project, repository, prID, apiVersion)
+// GetPRCommits fetches the source and target commits of a pull request's last merge
+func (c *Client) GetPRCommits(ctx context.Context, project, repository string, prID int) (PRCommits, error) {
+	prURL := c.urls.API(project, repositoryPath(repository, fmt.Sprintf("pullRequests/%d", prID)))
 
-	body, err := c.doRequest(prURL)
+	body, err := c.doRequest(ctx, prURL)
 	if err != nil {
-		return "", err
+		return PRCommits{}, err
 	}
 
 	var pr struct {
@@ -359,109 +581,83 @@ func (c *Client) GetPRFileDiff(project, repository string, prID int, filePath st
 	}
 
 	if err := json.Unmarshal(body, &pr); err != nil {
-		return "", fmt.Errorf("failed to parse PR details: %w", err)
+		return PRCommits{}, fmt.Errorf("failed to parse PR details: %w", err)
 	}
 
-	// Get the file content from target commit (base)
-	targetURL := fmt.Sprintf("%s/%s/%s/_apis/git/repositories/%s/items?path=%s&versionDescriptor.versionType=commit&versionDescriptor.version=%s&api-version=%s",
-		baseURL, c.organization, project, repository, filePath, pr.LastMergeTargetCommit.CommitID, apiVersion)
+	return PRCommits{
+		SourceCommitID: pr.LastMergeSourceCommit.CommitID,
+		TargetCommitID: pr.LastMergeTargetCommit.CommitID,
+	}, nil
+}
 
-	targetContent, err := c.doRequest(targetURL)
-	targetText := ""
-	isNewFile := false
+// GetPRFileDiff fetches the diff for a changed file of a pull request.
+// contextLines is the number of unchanged lines shown around each change.
+func (c *Client) GetPRFileDiff(ctx context.Context, project, repository string, prID int, change PRChange, contextLines int) (FileDiff, error) {
+	commits, err := c.GetPRCommits(ctx, project, repository, prID)
 	if err != nil {
-		isNewFile = true
-		targetText = ""
-	} else {
-		targetText = string(targetContent)
+		return FileDiff{}, err
 	}
 
-	// Get the file content from source commit (new)
-	sourceURL := fmt.Sprintf("%s/%s/%s/_apis/git/repositories/%s/items?path=%s&versionDescriptor.versionType=commit&versionDescriptor.version=%s&api-version=%s",
-		baseURL, c.organization, project, repository, filePath, pr.LastMergeSourceCommit.CommitID, apiVersion)
+	return c.GetFileDiff(ctx, project, repository, commits, change, contextLines)
+}
 
-	sourceContent, err := c.doRequest(sourceURL)
-	sourceText := ""
-	isDeletedFile := false
-	if err != nil {
-		isDeletedFile = true
-		sourceText = ""
-	} else {
-		sourceText = string(sourceContent)
-	}
-
-	// Generate unified diff
-	dmp := diffmatchpatch.New()
-	diffs := dmp.DiffMain(targetText, sourceText, false)
-
-	// Convert to unified diff format
-	var result strings.Builder
-	result.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n", filePath, filePath))
-
-	if isNewFile {
-		result.WriteString("new file\n")
-		result.WriteString(fmt.Sprintf("--- /dev/null\n"))
-		result.WriteString(fmt.Sprintf("+++ b/%s\n", filePath))
-		// For new files, show all content as additions
-		lines := strings.Split(sourceText, "\n")
-		if len(lines) > 0 {
-			result.WriteString("@@ -0,0 +1," + fmt.Sprintf("%d", len(lines)) + " @@\n")
-			for _, line := range lines {
-				result.WriteString("+" + line + "\n")
-			}
-		}
-	} else if isDeletedFile {
-		result.WriteString("deleted file\n")
-		result.WriteString(fmt.Sprintf("--- a/%s\n", filePath))
-		result.WriteString(fmt.Sprintf("+++ /dev/null\n"))
-		// For deleted files, show all content as deletions
-		lines := strings.Split(targetText, "\n")
-		if len(lines) > 0 {
-			result.WriteString("@@ -1," + fmt.Sprintf("%d", len(lines)) + " +0,0 @@\n")
-			for _, line := range lines {
-				result.WriteString("-" + line + "\n")
-			}
+// GetFileDiff fetches the diff for a changed file between the given PR commits.
+// Callers that diff several files of the same PR should fetch the commits once
+// with GetPRCommits so each file only costs the two content fetches.
+func (c *Client) GetFileDiff(ctx context.Context, project, repository string, commits PRCommits, change PRChange, contextLines int) (FileDiff, error) {
+	filePath := change.Item.Path
+	targetPath := filePath
+	if change.OriginalPath != "" {
+		targetPath = change.OriginalPath
+	}
+
+	// The change type says which side is missing, so any failed fetch is an error
+	// rather than a sign of a new or deleted file
+	isNewFile := change.HasChangeType(ChangeTypeAdd)
+	isDeletedFile := change.HasChangeType(ChangeTypeDelete)
+
+	// Get the file content from target commit (base)
+	targetText := ""
+	if !isNewFile {
+		content, err := c.getItemContent(ctx, project, repository, targetPath, commits.TargetCommitID)
+		if err != nil {
+			return FileDiff{}, err
 		}
-	} else {
-		result.WriteString(fmt.Sprintf("--- a/%s\n", filePath))
-		result.WriteString(fmt.Sprintf("+++ b/%s\n", filePath))
-
-		// Generate line-by-line diff manually
-		targetLines := strings.Split(targetText, "\n")
-		sourceLines := strings.Split(sourceText, "\n")
-
-		// Simple line-by-line comparison
-		result.WriteString(fmt.Sprintf("@@ -1,%d +1,%d @@\n", len(targetLines), len(sourceLines)))
-
-		// Show the diff using the dmp library's results
-		for _, diff := range diffs {
-			lines := strings.Split(diff.Text, "\n")
-			for i, line := range lines {
-				// Skip empty last line from split
-				if i == len(lines)-1 && line == "" {
-					continue
-				}
-
-				switch diff.Type {
-				case diffmatchpatch.DiffInsert:
-					result.WriteString("+" + line + "\n")
-				case diffmatchpatch.DiffDelete:
-					result.WriteString("-" + line + "\n")
-				case diffmatchpatch.DiffEqual:
-					result.WriteString(" " + line + "\n")
-				}
-			}
+		targetText = content
+	}
+
+	// Get the file content from source commit (new)
+	sourceText := ""
+	if !isDeletedFile {
+		content, err := c.getItemContent(ctx, project, repository, filePath, commits.SourceCommitID)
+		if err != nil {
+			return FileDiff{}, err
 		}
+		sourceText = content
+	}
+
+	return ComputeDiff(targetPath, filePath, targetText, sourceText, isNewFile, isDeletedFile, contextLines), nil
+}
+
+// getItemContent fetches the content of a file at a commit
+func (c *Client) getItemContent(ctx context.Context, project, repository, path, commitID string) (string, error) {
+	url := c.urls.API(project, repositoryPath(repository, fmt.Sprintf("items?path=%s&versionDescriptor.versionType=commit&versionDescriptor.version=%s",
+		neturl.QueryEscape(path), commitID)))
+
+	body, err := c.doRequest(ctx, url)
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s at %.8s: %w", path, commitID, err)
 	}
 
-	return result.String(), nil
+	return string(body), nil
 }
 
 // BuildLog represents a build log
 type BuildLog struct {
-	ID   int    `json:"id"`
-	Type string `json:"type"`
-	URL  string `json:"url"`
+	ID        int    `json:"id"`
+	Type      string `json:"type"`
+	URL       string `json:"url"`
+	LineCount int    `json:"lineCount"`
 }
 
 // BuildLogsResponse represents the API response for build logs
@@ -471,11 +667,10 @@ type BuildLogsResponse struct {
 }
 
 // GetBuildLogs fetches the list of logs for a build
-func (c *Client) GetBuildLogs(project string, buildID int) ([]BuildLog, error) {
-	url := fmt.Sprintf("%s/%s/%s/_apis/build/builds/%d/logs?api-version=%s",
-		baseURL, c.organization, project, buildID, apiVersion)
+func (c *Client) GetBuildLogs(ctx context.Context, project string, buildID int) ([]BuildLog, error) {
+	url := c.urls.API(project, fmt.Sprintf("build/builds/%d/logs", buildID))
 
-	body, err := c.doRequest(url)
+	body, err := c.doRequest(ctx, url)
 	if err != nil {
 		return nil, err
 	}
@@ -489,11 +684,22 @@ func (c *Client) GetBuildLogs(project string, buildID int) ([]BuildLog, error) {
 }
 
 // GetBuildLogContent fetches the content of a specific build log
-func (c *Client) GetBuildLogContent(project string, buildID int, logID int) (string, error) {
-	url := fmt.Sprintf("%s/%s/%s/_apis/build/builds/%d/logs/%d?api-version=%s",
-		baseURL, c.organization, project, buildID, logID, apiVersion)
+func (c *Client) GetBuildLogContent(ctx context.Context, project string, buildID int, logID int) (string, error) {
+	url := c.urls.API(project, fmt.Sprintf("build/builds/%d/logs/%d", buildID, logID))
+
+	body, err := c.doRequest(ctx, url)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
+
+// GetBuildLogLines fetches lines startLine to endLine (1-based, inclusive) of a build log
+func (c *Client) GetBuildLogLines(ctx context.Context, project string, buildID, logID, startLine, endLine int) (string, error) {
+	url := c.urls.API(project, fmt.Sprintf("build/builds/%d/logs/%d?startLine=%d&endLine=%d", buildID, logID, startLine, endLine))
 
-	body, err := c.doRequest(url)
+	body, err := c.doRequest(ctx, url)
 	if err != nil {
 		return "", err
 	}
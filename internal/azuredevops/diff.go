@@ -0,0 +1,415 @@
+package azuredevops
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// DiffLineKind identifies the kind of a line in a diff hunk
+type DiffLineKind int
+
+const (
+	DiffContext DiffLineKind = iota
+	DiffAdded
+	DiffDeleted
+)
+
+// DiffLine represents a single line of a diff hunk
+type DiffLine struct {
+	Kind      DiffLineKind
+	Text      string // Line content without the trailing newline
+	OldLine   int    // Line number on the base side, 0 for added lines
+	NewLine   int    // Line number on the PR side, 0 for deleted lines
+	NoNewline bool   // The line is the last in its file and has no trailing newline
+}
+
+// DiffHunk represents a contiguous group of changes with surrounding context
+type DiffHunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []DiffLine
+}
+
+// Header returns the hunk header in unified diff format
+func (h DiffHunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+}
+
+// hunkRange formats one side of a hunk header the way git does
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// FileDiff represents the line-based diff of a single file
+type FileDiff struct {
+	OldPath   string // Path on the target branch, without leading slash
+	NewPath   string // Path on the source branch, without leading slash
+	IsNew     bool
+	IsDeleted bool
+	IsBinary  bool
+	Hunks     []DiffHunk
+	OldText   string // Full content on the target branch
+	NewText   string // Full content on the source branch
+}
+
+// Unified returns the diff as a patch that can be applied with git apply
+func (d FileDiff) Unified() string {
+	var result strings.Builder
+
+	result.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n", d.OldPath, d.NewPath))
+	switch {
+	case d.IsNew:
+		result.WriteString("new file mode 100644\n")
+	case d.IsDeleted:
+		result.WriteString("deleted file mode 100644\n")
+	case d.OldPath != d.NewPath:
+		result.WriteString(fmt.Sprintf("rename from %s\n", d.OldPath))
+		result.WriteString(fmt.Sprintf("rename to %s\n", d.NewPath))
+	}
+
+	if d.IsBinary {
+		result.WriteString(fmt.Sprintf("Binary files a/%s and b/%s differ\n", d.OldPath, d.NewPath))
+		return result.String()
+	}
+
+	if len(d.Hunks) == 0 {
+		return result.String()
+	}
+
+	if d.IsNew {
+		result.WriteString("--- /dev/null\n")
+	} else {
+		result.WriteString(fmt.Sprintf("--- a/%s\n", d.OldPath))
+	}
+	if d.IsDeleted {
+		result.WriteString("+++ /dev/null\n")
+	} else {
+		result.WriteString(fmt.Sprintf("+++ b/%s\n", d.NewPath))
+	}
+
+	for _, hunk := range d.Hunks {
+		result.WriteString(hunk.Header() + "\n")
+		for _, line := range hunk.Lines {
+			switch line.Kind {
+			case DiffAdded:
+				result.WriteString("+")
+			case DiffDeleted:
+				result.WriteString("-")
+			default:
+				result.WriteString(" ")
+			}
+			result.WriteString(line.Text + "\n")
+			if line.NoNewline {
+				result.WriteString("\\ No newline at end of file\n")
+			}
+		}
+	}
+
+	return result.String()
+}
+
+// Stats returns the number of added and deleted lines in the diff
+func (d FileDiff) Stats() (added, deleted int) {
+	for _, hunk := range d.Hunks {
+		for _, line := range hunk.Lines {
+			switch line.Kind {
+			case DiffAdded:
+				added++
+			case DiffDeleted:
+				deleted++
+			}
+		}
+	}
+	return added, deleted
+}
+
+// ComputeDiff computes a line-based diff between two versions of a file,
+// grouping changes into hunks with the given number of context lines
+func ComputeDiff(oldPath, newPath, oldText, newText string, isNew, isDeleted bool, contextLines int) FileDiff {
+	diff := FileDiff{
+		OldPath:   strings.TrimPrefix(oldPath, "/"),
+		NewPath:   strings.TrimPrefix(newPath, "/"),
+		IsNew:     isNew,
+		IsDeleted: isDeleted,
+		OldText:   oldText,
+		NewText:   newText,
+	}
+
+	if strings.ContainsRune(oldText, 0) || strings.ContainsRune(newText, 0) {
+		diff.IsBinary = true
+		return diff
+	}
+
+	diff.Hunks = buildHunks(diffLines(oldText, newText), contextLines)
+	return diff
+}
+
+// diffLines compares two texts line by line and returns every line of both
+// texts in order, tagged as context, added or deleted
+func diffLines(oldText, newText string) []DiffLine {
+	oldLines := splitLines(oldText)
+	newLines := splitLines(newText)
+
+	// Map each distinct line to a rune so the character diff works on whole lines
+	lineIDs := make(map[string]rune)
+	encode := func(lines []string) []rune {
+		runes := make([]rune, len(lines))
+		for i, line := range lines {
+			id, ok := lineIDs[line]
+			if !ok {
+				id = lineRune(len(lineIDs))
+				lineIDs[line] = id
+			}
+			runes[i] = id
+		}
+		return runes
+	}
+
+	dmp := diffmatchpatch.New()
+	diffs := dmp.DiffMainRunes(encode(oldLines), encode(newLines), false)
+
+	var lines []DiffLine
+	oldIndex, newIndex := 0, 0
+	for _, d := range diffs {
+		count := len([]rune(d.Text))
+		for i := 0; i < count; i++ {
+			switch d.Type {
+			case diffmatchpatch.DiffEqual:
+				lines = append(lines, newDiffLine(DiffContext, oldLines[oldIndex], oldIndex+1, newIndex+1))
+				oldIndex++
+				newIndex++
+			case diffmatchpatch.DiffDelete:
+				lines = append(lines, newDiffLine(DiffDeleted, oldLines[oldIndex], oldIndex+1, 0))
+				oldIndex++
+			case diffmatchpatch.DiffInsert:
+				lines = append(lines, newDiffLine(DiffAdded, newLines[newIndex], 0, newIndex+1))
+				newIndex++
+			}
+		}
+	}
+
+	return lines
+}
+
+// lineRune maps a line ID to a valid rune, skipping NUL and the surrogate range
+func lineRune(id int) rune {
+	r := rune(id + 1)
+	if r >= 0xD800 {
+		r += 0x800
+	}
+	return r
+}
+
+// splitLines splits text into lines that keep their trailing newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// newDiffLine creates a diff line from a raw line that may end in a newline
+func newDiffLine(kind DiffLineKind, raw string, oldLine, newLine int) DiffLine {
+	return DiffLine{
+		Kind:      kind,
+		Text:      strings.TrimSuffix(raw, "\n"),
+		OldLine:   oldLine,
+		NewLine:   newLine,
+		NoNewline: !strings.HasSuffix(raw, "\n"),
+	}
+}
+
+// buildHunks groups changed lines into hunks, keeping contextLines unchanged
+// lines around each change and merging hunks whose context overlaps
+func buildHunks(lines []DiffLine, contextLines int) []DiffHunk {
+	if contextLines < 0 {
+		contextLines = 0
+	}
+
+	var hunks []DiffHunk
+	start, end := -1, -1 // Range of lines in the current hunk
+
+	flush := func() {
+		if start < 0 {
+			return
+		}
+		hunks = append(hunks, newHunk(lines, start, end))
+		start, end = -1, -1
+	}
+
+	for i, line := range lines {
+		if line.Kind == DiffContext {
+			continue
+		}
+
+		from := i - contextLines
+		if from < 0 {
+			from = 0
+		}
+		to := i + contextLines
+		if to > len(lines)-1 {
+			to = len(lines) - 1
+		}
+
+		if start >= 0 && from > end+1 {
+			flush()
+		}
+		if start < 0 {
+			start = from
+		}
+		if to > end {
+			end = to
+		}
+	}
+	flush()
+
+	return hunks
+}
+
+// newHunk creates a hunk from lines[start:end+1] and computes its header ranges
+func newHunk(lines []DiffLine, start, end int) DiffHunk {
+	hunk := DiffHunk{Lines: lines[start : end+1]}
+
+	// Line numbers before the hunk, used when a side has no lines in the hunk
+	oldBefore, newBefore := 0, 0
+	for _, line := range lines[:start] {
+		if line.Kind != DiffAdded {
+			oldBefore++
+		}
+		if line.Kind != DiffDeleted {
+			newBefore++
+		}
+	}
+
+	for _, line := range hunk.Lines {
+		if line.Kind != DiffAdded {
+			hunk.OldLines++
+		}
+		if line.Kind != DiffDeleted {
+			hunk.NewLines++
+		}
+	}
+
+	hunk.OldStart = oldBefore
+	if hunk.OldLines > 0 {
+		hunk.OldStart++
+	}
+	hunk.NewStart = newBefore
+	if hunk.NewLines > 0 {
+		hunk.NewStart++
+	}
+
+	return hunk
+}
+
+// DiffSegment is a piece of a line in a word-level diff
+type DiffSegment struct {
+	Text    string
+	Changed bool // The segment differs from the other side
+}
+
+// DiffWords compares two lines word by word and returns the segments of each
+// line, marking the words that were changed
+func DiffWords(oldLine, newLine string) ([]DiffSegment, []DiffSegment) {
+	oldWords := splitWords(oldLine)
+	newWords := splitWords(newLine)
+
+	wordIDs := make(map[string]rune)
+	encode := func(words []string) []rune {
+		runes := make([]rune, len(words))
+		for i, word := range words {
+			id, ok := wordIDs[word]
+			if !ok {
+				id = lineRune(len(wordIDs))
+				wordIDs[word] = id
+			}
+			runes[i] = id
+		}
+		return runes
+	}
+
+	dmp := diffmatchpatch.New()
+	diffs := dmp.DiffMainRunes(encode(oldWords), encode(newWords), false)
+
+	var oldSegments, newSegments []DiffSegment
+	oldIndex, newIndex := 0, 0
+	for _, d := range diffs {
+		count := len([]rune(d.Text))
+		for i := 0; i < count; i++ {
+			switch d.Type {
+			case diffmatchpatch.DiffEqual:
+				oldSegments = appendSegment(oldSegments, oldWords[oldIndex], false)
+				newSegments = appendSegment(newSegments, newWords[newIndex], false)
+				oldIndex++
+				newIndex++
+			case diffmatchpatch.DiffDelete:
+				oldSegments = appendSegment(oldSegments, oldWords[oldIndex], true)
+				oldIndex++
+			case diffmatchpatch.DiffInsert:
+				newSegments = appendSegment(newSegments, newWords[newIndex], true)
+				newIndex++
+			}
+		}
+	}
+
+	return oldSegments, newSegments
+}
+
+// appendSegment adds a word to the segments, merging it with the previous
+// segment when both have the same changed state
+func appendSegment(segments []DiffSegment, word string, changed bool) []DiffSegment {
+	if n := len(segments); n > 0 && segments[n-1].Changed == changed {
+		segments[n-1].Text += word
+		return segments
+	}
+	return append(segments, DiffSegment{Text: word, Changed: changed})
+}
+
+// splitWords splits a line into runs of letters and digits, runs of whitespace
+// and single punctuation characters
+func splitWords(line string) []string {
+	var words []string
+	var current strings.Builder
+	currentClass := -1
+
+	for _, r := range line {
+		class := runeClass(r)
+		if class != currentClass || class == 2 {
+			if current.Len() > 0 {
+				words = append(words, current.String())
+				current.Reset()
+			}
+			currentClass = class
+		}
+		current.WriteRune(r)
+	}
+	if current.Len() > 0 {
+		words = append(words, current.String())
+	}
+
+	return words
+}
+
+// runeClass classifies a rune as part of a word (0), whitespace (1) or punctuation (2)
+func runeClass(r rune) int {
+	switch {
+	case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+		return 0
+	case unicode.IsSpace(r):
+		return 1
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,188 @@
+package azuredevops
+
+import "testing"
+
+func TestComputeDiffUnified(t *testing.T) {
+	tests := []struct {
+		name         string
+		oldPath      string
+		newPath      string
+		oldText      string
+		newText      string
+		isNew        bool
+		isDeleted    bool
+		contextLines int
+		want         string
+	}{
+		{
+			name:         "edit",
+			oldPath:      "/src/f.txt",
+			newPath:      "/src/f.txt",
+			oldText:      "a\nb\nc\n",
+			newText:      "a\nB\nc\n",
+			contextLines: 3,
+			want: "diff --git a/src/f.txt b/src/f.txt\n" +
+				"--- a/src/f.txt\n" +
+				"+++ b/src/f.txt\n" +
+				"@@ -1,3 +1,3 @@\n" +
+				" a\n" +
+				"-b\n" +
+				"+B\n" +
+				" c\n",
+		},
+		{
+			name:         "add",
+			oldPath:      "/new.txt",
+			newPath:      "/new.txt",
+			newText:      "x\ny\n",
+			isNew:        true,
+			contextLines: 3,
+			want: "diff --git a/new.txt b/new.txt\n" +
+				"new file mode 100644\n" +
+				"--- /dev/null\n" +
+				"+++ b/new.txt\n" +
+				"@@ -0,0 +1,2 @@\n" +
+				"+x\n" +
+				"+y\n",
+		},
+		{
+			name:         "delete",
+			oldPath:      "/gone.txt",
+			newPath:      "/gone.txt",
+			oldText:      "x\n",
+			isDeleted:    true,
+			contextLines: 3,
+			want: "diff --git a/gone.txt b/gone.txt\n" +
+				"deleted file mode 100644\n" +
+				"--- a/gone.txt\n" +
+				"+++ /dev/null\n" +
+				"@@ -1 +0,0 @@\n" +
+				"-x\n",
+		},
+		{
+			name:         "no trailing newline",
+			oldPath:      "/f.txt",
+			newPath:      "/f.txt",
+			oldText:      "a\nb",
+			newText:      "a\nc",
+			contextLines: 3,
+			want: "diff --git a/f.txt b/f.txt\n" +
+				"--- a/f.txt\n" +
+				"+++ b/f.txt\n" +
+				"@@ -1,2 +1,2 @@\n" +
+				" a\n" +
+				"-b\n" +
+				"\\ No newline at end of file\n" +
+				"+c\n" +
+				"\\ No newline at end of file\n",
+		},
+		{
+			name:         "trailing newline added",
+			oldPath:      "/f.txt",
+			newPath:      "/f.txt",
+			oldText:      "a",
+			newText:      "a\n",
+			contextLines: 3,
+			want: "diff --git a/f.txt b/f.txt\n" +
+				"--- a/f.txt\n" +
+				"+++ b/f.txt\n" +
+				"@@ -1 +1 @@\n" +
+				"-a\n" +
+				"\\ No newline at end of file\n" +
+				"+a\n",
+		},
+		{
+			name:         "rename with edit",
+			oldPath:      "/old.txt",
+			newPath:      "/new.txt",
+			oldText:      "a\n",
+			newText:      "b\n",
+			contextLines: 3,
+			want: "diff --git a/old.txt b/new.txt\n" +
+				"rename from old.txt\n" +
+				"rename to new.txt\n" +
+				"--- a/old.txt\n" +
+				"+++ b/new.txt\n" +
+				"@@ -1 +1 @@\n" +
+				"-a\n" +
+				"+b\n",
+		},
+		{
+			name:         "pure rename",
+			oldPath:      "/old.txt",
+			newPath:      "/new.txt",
+			oldText:      "a\n",
+			newText:      "a\n",
+			contextLines: 3,
+			want: "diff --git a/old.txt b/new.txt\n" +
+				"rename from old.txt\n" +
+				"rename to new.txt\n",
+		},
+		{
+			name:         "separate hunks",
+			oldPath:      "/f.txt",
+			newPath:      "/f.txt",
+			oldText:      "1\n2\n3\n4\n5\n6\n7\n8\n",
+			newText:      "1\nTWO\n3\n4\n5\n6\n7\nEIGHT\n",
+			contextLines: 1,
+			want: "diff --git a/f.txt b/f.txt\n" +
+				"--- a/f.txt\n" +
+				"+++ b/f.txt\n" +
+				"@@ -1,3 +1,3 @@\n" +
+				" 1\n" +
+				"-2\n" +
+				"+TWO\n" +
+				" 3\n" +
+				"@@ -7,2 +7,2 @@\n" +
+				" 7\n" +
+				"-8\n" +
+				"+EIGHT\n",
+		},
+		{
+			name:         "overlapping context merges hunks",
+			oldPath:      "/f.txt",
+			newPath:      "/f.txt",
+			oldText:      "1\n2\n3\n4\n",
+			newText:      "ONE\n2\n3\nFOUR\n",
+			contextLines: 1,
+			want: "diff --git a/f.txt b/f.txt\n" +
+				"--- a/f.txt\n" +
+				"+++ b/f.txt\n" +
+				"@@ -1,4 +1,4 @@\n" +
+				"-1\n" +
+				"+ONE\n" +
+				" 2\n" +
+				" 3\n" +
+				"-4\n" +
+				"+FOUR\n",
+		},
+		{
+			name:         "binary",
+			oldPath:      "/img.png",
+			newPath:      "/img.png",
+			oldText:      "a\x00b",
+			newText:      "a\x00c",
+			contextLines: 3,
+			want: "diff --git a/img.png b/img.png\n" +
+				"Binary files a/img.png and b/img.png differ\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := ComputeDiff(tt.oldPath, tt.newPath, tt.oldText, tt.newText, tt.isNew, tt.isDeleted, tt.contextLines)
+			if got := diff.Unified(); got != tt.want {
+				t.Errorf("Unified() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileDiffStats(t *testing.T) {
+	diff := ComputeDiff("/f.txt", "/f.txt", "a\nb\nc\n", "a\nB\nc\nd\n", false, false, 3)
+
+	added, deleted := diff.Stats()
+	if added != 2 || deleted != 1 {
+		t.Errorf("Stats() = +%d -%d, want +2 -1", added, deleted)
+	}
+}
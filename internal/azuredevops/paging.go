@@ -0,0 +1,93 @@
+package azuredevops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// defaultPageSize is the number of items requested per page
+	defaultPageSize = 100
+
+	// continuationTokenHeader is the response header Azure DevOps uses to
+	// point at the next page of a list result
+	continuationTokenHeader = "x-ms-continuationtoken"
+)
+
+// listResponse represents the envelope Azure DevOps wraps list results in
+type listResponse[T any] struct {
+	Value []T `json:"value"`
+	Count int `json:"count"`
+}
+
+// getPaged fetches every page of a list endpoint and returns the combined items.
+// Endpoints that return an x-ms-continuationtoken header with the first page are
+// followed by token only, all others with $skip only; the two are never mixed
+// since token-paged endpoints such as builds ignore $skip. A limit of 0 or less
+// fetches all items.
+func getPaged[T any](ctx context.Context, c *Client, rawURL string, limit int) ([]T, error) {
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request URL: %w", err)
+	}
+
+	var items []T
+	continuationToken := ""
+	tokenPaged := false
+
+	for page := 0; ; page++ {
+		pageSize := defaultPageSize
+		if limit > 0 && limit-len(items) < pageSize {
+			pageSize = limit - len(items)
+		}
+
+		query := base.Query()
+		query.Set("$top", strconv.Itoa(pageSize))
+		if tokenPaged {
+			query.Set("continuationToken", continuationToken)
+		} else if page > 0 {
+			query.Set("$skip", strconv.Itoa(len(items)))
+		}
+
+		pageURL := *base
+		pageURL.RawQuery = query.Encode()
+
+		body, header, err := c.doRequestWithHeader(ctx, pageURL.String())
+		if err != nil {
+			return nil, err
+		}
+
+		var result listResponse[T]
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse list response: %w", err)
+		}
+
+		items = append(items, result.Value...)
+
+		if limit > 0 && len(items) >= limit {
+			return items[:limit], nil
+		}
+		if len(result.Value) == 0 {
+			return items, nil
+		}
+
+		// The first page decides how the rest are fetched
+		nextToken := header.Get(continuationTokenHeader)
+		if page == 0 {
+			tokenPaged = nextToken != ""
+		}
+
+		if tokenPaged {
+			// A missing or repeated token means there are no further pages
+			if nextToken == "" || nextToken == continuationToken {
+				return items, nil
+			}
+			continuationToken = nextToken
+		} else if len(result.Value) < pageSize {
+			return items, nil
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package azuredevops
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// pagedServer serves the items 1..total as a list endpoint. With tokens set
+// it pages by continuation token and ignores $skip, like the builds API;
+// otherwise it pages by $skip. Every request's query is recorded.
+func pagedServer(t *testing.T, total int, tokens bool, queries *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		*queries = append(*queries, r.URL.RawQuery)
+
+		top, err := strconv.Atoi(query.Get("$top"))
+		if err != nil {
+			t.Errorf("request without $top: %s", r.URL)
+		}
+		start := 0
+		if tokens {
+			if token := query.Get("continuationToken"); token != "" {
+				start, _ = strconv.Atoi(token)
+			}
+		} else if skip := query.Get("$skip"); skip != "" {
+			start, _ = strconv.Atoi(skip)
+		}
+
+		var page listResponse[int]
+		for i := start; i < total && len(page.Value) < top; i++ {
+			page.Value = append(page.Value, i+1)
+		}
+		page.Count = len(page.Value)
+		if next := start + len(page.Value); tokens && next < total {
+			w.Header().Set(continuationTokenHeader, strconv.Itoa(next))
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+}
+
+func TestGetPaged(t *testing.T) {
+	tests := []struct {
+		name        string
+		total       int
+		tokens      bool
+		limit       int
+		wantItems   int
+		wantQueries []string
+	}{
+		{
+			name:      "token chain",
+			total:     250,
+			tokens:    true,
+			wantItems: 250,
+			wantQueries: []string{
+				"%24top=100",
+				"%24top=100&continuationToken=100",
+				"%24top=100&continuationToken=200",
+			},
+		},
+		{
+			name:      "skip paging ends on a short page",
+			total:     150,
+			wantItems: 150,
+			wantQueries: []string{
+				"%24top=100",
+				"%24skip=100&%24top=100",
+			},
+		},
+		{
+			name:      "skip paging ends on an empty page",
+			total:     200,
+			wantItems: 200,
+			wantQueries: []string{
+				"%24top=100",
+				"%24skip=100&%24top=100",
+				"%24skip=200&%24top=100",
+			},
+		},
+		{
+			name:      "limit truncates skip paging",
+			total:     250,
+			limit:     120,
+			wantItems: 120,
+			wantQueries: []string{
+				"%24top=100",
+				"%24skip=100&%24top=20",
+			},
+		},
+		{
+			name:      "limit truncates a token chain",
+			total:     250,
+			tokens:    true,
+			limit:     150,
+			wantItems: 150,
+			wantQueries: []string{
+				"%24top=100",
+				"%24top=50&continuationToken=100",
+			},
+		},
+		{
+			name:      "limit below a page",
+			total:     250,
+			limit:     5,
+			wantItems: 5,
+			wantQueries: []string{
+				"%24top=5",
+			},
+		},
+		{
+			name:        "empty list",
+			total:       0,
+			wantQueries: []string{"%24top=100"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queries []string
+			server := pagedServer(t, tt.total, tt.tokens, &queries)
+			defer server.Close()
+
+			c := NewClient(server.URL, DefaultAPIVersion, "pat")
+			items, err := getPaged[int](context.Background(), c, server.URL+"/items", tt.limit)
+			if err != nil {
+				t.Fatalf("getPaged() error = %v", err)
+			}
+
+			if len(items) != tt.wantItems {
+				t.Errorf("getPaged() returned %d items, want %d", len(items), tt.wantItems)
+			}
+			for i, item := range items {
+				if item != i+1 {
+					t.Errorf("item %d = %d, want %d", i, item, i+1)
+					break
+				}
+			}
+			if !reflect.DeepEqual(queries, tt.wantQueries) {
+				t.Errorf("queries = %q, want %q", queries, tt.wantQueries)
+			}
+		})
+	}
+}
+
+func TestGetPagedStopsOnRepeatedToken(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set(continuationTokenHeader, "same")
+		json.NewEncoder(w).Encode(listResponse[int]{Value: []int{requests}, Count: 1})
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, DefaultAPIVersion, "pat")
+	items, err := getPaged[int](context.Background(), c, server.URL+"/items", 0)
+	if err != nil {
+		t.Fatalf("getPaged() error = %v", err)
+	}
+	if !reflect.DeepEqual(items, []int{1, 2}) {
+		t.Errorf("getPaged() = %v, want [1 2]", items)
+	}
+}
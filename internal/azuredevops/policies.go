@@ -0,0 +1,216 @@
+package azuredevops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	neturl "net/url"
+)
+
+// Policy evaluation statuses
+const (
+	PolicyStatusApproved      = "approved"
+	PolicyStatusRejected      = "rejected"
+	PolicyStatusRunning       = "running"
+	PolicyStatusQueued        = "queued"
+	PolicyStatusNotApplicable = "notApplicable"
+	PolicyStatusBroken        = "broken"
+)
+
+// PolicyEvaluation is the state of a branch policy for a pull request
+type PolicyEvaluation struct {
+	EvaluationID  string              `json:"evaluationId"`
+	Status        string              `json:"status"`
+	Configuration PolicyConfiguration `json:"configuration"`
+	Context       PolicyContext       `json:"context"`
+}
+
+// PolicyConfiguration describes a branch policy
+type PolicyConfiguration struct {
+	ID         int        `json:"id"`
+	IsEnabled  bool       `json:"isEnabled"`
+	IsBlocking bool       `json:"isBlocking"`
+	Type       PolicyType `json:"type"`
+	Settings   struct {
+		DisplayName          string `json:"displayName"`          // Build policies
+		MinimumApproverCount int    `json:"minimumApproverCount"` // Minimum reviewers policies
+		BuildDefinitionID    int    `json:"buildDefinitionId"`    // Build policies
+	} `json:"settings"`
+}
+
+// PolicyType identifies the kind of a branch policy
+type PolicyType struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+}
+
+// PolicyContext holds evaluation details that depend on the policy type
+type PolicyContext struct {
+	BuildID int `json:"buildId"` // Build that validates the PR, for build policies
+}
+
+// Name returns a descriptive name for the evaluated policy
+func (e PolicyEvaluation) Name() string {
+	settings := e.Configuration.Settings
+	switch {
+	case settings.DisplayName != "":
+		return fmt.Sprintf("%s: %s", e.Configuration.Type.DisplayName, settings.DisplayName)
+	case settings.MinimumApproverCount > 0:
+		return fmt.Sprintf("%s (%d)", e.Configuration.Type.DisplayName, settings.MinimumApproverCount)
+	}
+	return e.Configuration.Type.DisplayName
+}
+
+// Blocks reports whether the policy currently prevents the PR from completing
+func (e PolicyEvaluation) Blocks() bool {
+	if !e.Configuration.IsEnabled || !e.Configuration.IsBlocking {
+		return false
+	}
+	return e.Status != PolicyStatusApproved && e.Status != PolicyStatusNotApplicable
+}
+
+// GetPolicyEvaluations fetches the branch policy evaluations of a pull request
+func (c *Client) GetPolicyEvaluations(ctx context.Context, project, projectID string, prID int) ([]PolicyEvaluation, error) {
+	artifactID := fmt.Sprintf("vstfs:///CodeReview/CodeReviewId/%s/%d", projectID, prID)
+	url := c.urls.PreviewAPI(project, "policy/evaluations?artifactId="+neturl.QueryEscape(artifactID))
+
+	body, err := c.doRequest(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy evaluations: %w", err)
+	}
+
+	var response listResponse[PolicyEvaluation]
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse policy evaluations: %w", err)
+	}
+
+	return response.Value, nil
+}
+
+// Pull request status states
+const (
+	StatusStateSucceeded     = "succeeded"
+	StatusStateFailed        = "failed"
+	StatusStateError         = "error"
+	StatusStatePending       = "pending"
+	StatusStateNotSet        = "notSet"
+	StatusStateNotApplicable = "notApplicable"
+)
+
+// PRStatus is a status posted to a pull request by an external service
+type PRStatus struct {
+	ID          int           `json:"id"`
+	State       string        `json:"state"`
+	Description string        `json:"description"`
+	Context     StatusContext `json:"context"`
+	TargetURL   string        `json:"targetUrl"`
+}
+
+// StatusContext identifies the service and check that posted a status
+type StatusContext struct {
+	Name  string `json:"name"`
+	Genre string `json:"genre"`
+}
+
+// Name returns the genre qualified name of the status
+func (s PRStatus) Name() string {
+	if s.Context.Genre == "" {
+		return s.Context.Name
+	}
+	return s.Context.Genre + "/" + s.Context.Name
+}
+
+// GetPRStatuses fetches the latest status of each check posted to a pull
+// request. Statuses are posted again for every iteration, so older ones with
+// the same context are dropped.
+func (c *Client) GetPRStatuses(ctx context.Context, project, repository string, prID int) ([]PRStatus, error) {
+	url := c.urls.PreviewAPI(project, repositoryPath(repository, fmt.Sprintf("pullRequests/%d/statuses", prID)))
+
+	body, err := c.doRequest(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pull request statuses: %w", err)
+	}
+
+	var response listResponse[PRStatus]
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse pull request statuses: %w", err)
+	}
+
+	var statuses []PRStatus
+	latest := make(map[StatusContext]int) // Index in statuses by context
+	for _, status := range response.Value {
+		index, ok := latest[status.Context]
+		switch {
+		case !ok:
+			latest[status.Context] = len(statuses)
+			statuses = append(statuses, status)
+		case status.ID > statuses[index].ID:
+			statuses[index] = status
+		}
+	}
+
+	return statuses, nil
+}
+
+// Overall states of the checks of a pull request
+const (
+	CheckPassed  = "passed"
+	CheckFailed  = "failed"
+	CheckPending = "pending"
+)
+
+// PRChecks holds the branch policy evaluations and statuses of a pull request
+type PRChecks struct {
+	Policies []PolicyEvaluation
+	Statuses []PRStatus
+}
+
+// State summarizes the checks as passed, failed or pending, or returns an
+// empty string when the PR has no checks. Only blocking policies can fail or
+// hold up a PR.
+func (c PRChecks) State() string {
+	if len(c.Policies) == 0 && len(c.Statuses) == 0 {
+		return ""
+	}
+
+	pending := false
+	for _, policy := range c.Policies {
+		if !policy.Blocks() {
+			continue
+		}
+		switch policy.Status {
+		case PolicyStatusRunning, PolicyStatusQueued:
+			pending = true
+		default:
+			return CheckFailed
+		}
+	}
+	for _, status := range c.Statuses {
+		switch status.State {
+		case StatusStateFailed, StatusStateError:
+			return CheckFailed
+		case StatusStatePending, StatusStateNotSet:
+			pending = true
+		}
+	}
+
+	if pending {
+		return CheckPending
+	}
+	return CheckPassed
+}
+
+// GetPRChecks fetches the branch policy evaluations and statuses of a pull request
+func (c *Client) GetPRChecks(ctx context.Context, project, projectID, repository string, prID int) (PRChecks, error) {
+	policies, err := c.GetPolicyEvaluations(ctx, project, projectID, prID)
+	if err != nil {
+		return PRChecks{}, err
+	}
+
+	statuses, err := c.GetPRStatuses(ctx, project, repository, prID)
+	if err != nil {
+		return PRChecks{}, err
+	}
+
+	return PRChecks{Policies: policies, Statuses: statuses}, nil
+}
@@ -0,0 +1,91 @@
+package azuredevops
+
+import "testing"
+
+func TestPRChecksState(t *testing.T) {
+	policy := func(status string, enabled, blocking bool) PolicyEvaluation {
+		var evaluation PolicyEvaluation
+		evaluation.Status = status
+		evaluation.Configuration.IsEnabled = enabled
+		evaluation.Configuration.IsBlocking = blocking
+		return evaluation
+	}
+	status := func(state string) PRStatus {
+		return PRStatus{State: state}
+	}
+
+	tests := []struct {
+		name   string
+		checks PRChecks
+		want   string
+	}{
+		{
+			name: "no checks",
+			want: "",
+		},
+		{
+			name: "approved policies and succeeded statuses",
+			checks: PRChecks{
+				Policies: []PolicyEvaluation{policy(PolicyStatusApproved, true, true), policy(PolicyStatusNotApplicable, true, true)},
+				Statuses: []PRStatus{status(StatusStateSucceeded), status(StatusStateNotApplicable)},
+			},
+			want: CheckPassed,
+		},
+		{
+			name:   "rejected blocking policy",
+			checks: PRChecks{Policies: []PolicyEvaluation{policy(PolicyStatusApproved, true, true), policy(PolicyStatusRejected, true, true)}},
+			want:   CheckFailed,
+		},
+		{
+			name:   "rejected optional policy",
+			checks: PRChecks{Policies: []PolicyEvaluation{policy(PolicyStatusRejected, true, false)}},
+			want:   CheckPassed,
+		},
+		{
+			name:   "rejected disabled policy",
+			checks: PRChecks{Policies: []PolicyEvaluation{policy(PolicyStatusRejected, false, true)}},
+			want:   CheckPassed,
+		},
+		{
+			name:   "running policy",
+			checks: PRChecks{Policies: []PolicyEvaluation{policy(PolicyStatusRunning, true, true), policy(PolicyStatusApproved, true, true)}},
+			want:   CheckPending,
+		},
+		{
+			name:   "queued policy",
+			checks: PRChecks{Policies: []PolicyEvaluation{policy(PolicyStatusQueued, true, true)}},
+			want:   CheckPending,
+		},
+		{
+			name: "failure wins over pending",
+			checks: PRChecks{
+				Policies: []PolicyEvaluation{policy(PolicyStatusRunning, true, true)},
+				Statuses: []PRStatus{status(StatusStateFailed)},
+			},
+			want: CheckFailed,
+		},
+		{
+			name:   "errored status",
+			checks: PRChecks{Statuses: []PRStatus{status(StatusStateError)}},
+			want:   CheckFailed,
+		},
+		{
+			name:   "pending status",
+			checks: PRChecks{Statuses: []PRStatus{status(StatusStateSucceeded), status(StatusStatePending)}},
+			want:   CheckPending,
+		},
+		{
+			name:   "status without state",
+			checks: PRChecks{Statuses: []PRStatus{status(StatusStateNotSet)}},
+			want:   CheckPending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.checks.State(); got != tt.want {
+				t.Errorf("State() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
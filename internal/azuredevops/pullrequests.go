@@ -0,0 +1,149 @@
+package azuredevops
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Merge strategies for completing a pull request
+const (
+	MergeStrategyNoFastForward = "noFastForward"
+	MergeStrategySquash        = "squash"
+	MergeStrategyRebase        = "rebase"
+	MergeStrategyRebaseMerge   = "rebaseMerge"
+)
+
+// MergeStrategies lists the merge strategies in the order they are offered
+var MergeStrategies = []string{
+	MergeStrategyNoFastForward,
+	MergeStrategySquash,
+	MergeStrategyRebase,
+	MergeStrategyRebaseMerge,
+}
+
+// MergeStrategyLabel returns the name Azure DevOps shows for a merge strategy
+func MergeStrategyLabel(strategy string) string {
+	switch strategy {
+	case MergeStrategySquash:
+		return "Squash commit"
+	case MergeStrategyRebase:
+		return "Rebase and fast-forward"
+	case MergeStrategyRebaseMerge:
+		return "Semi-linear merge"
+	}
+	return "Merge (no fast-forward)"
+}
+
+// CompletionOptions controls how a pull request is merged when it completes.
+// Policy bypasses are left out on purpose so they are never sent unasked.
+type CompletionOptions struct {
+	MergeStrategy               string `json:"mergeStrategy,omitempty"`
+	DeleteSourceBranch          bool   `json:"deleteSourceBranch"`
+	TransitionWorkItems         bool   `json:"transitionWorkItems"`
+	MergeCommitMessage          string `json:"mergeCommitMessage,omitempty"`
+	AutoCompleteIgnoreConfigIDs []int  `json:"autoCompleteIgnoreConfigIds,omitempty"`
+}
+
+// emptyIdentityID clears an identity reference, e.g. to cancel auto-complete
+const emptyIdentityID = "00000000-0000-0000-0000-000000000000"
+
+// pullRequestUpdate is the request body for updating a pull request. Only
+// the fields that are set are changed.
+type pullRequestUpdate struct {
+	Status                string             `json:"status,omitempty"`
+	IsDraft               *bool              `json:"isDraft,omitempty"`
+	LastMergeSourceCommit *CommitRef         `json:"lastMergeSourceCommit,omitempty"`
+	AutoCompleteSetBy     *identityRef       `json:"autoCompleteSetBy,omitempty"`
+	CompletionOptions     *CompletionOptions `json:"completionOptions,omitempty"`
+}
+
+// CommitRef references a commit by ID
+type CommitRef struct {
+	CommitID string `json:"commitId"`
+}
+
+// identityRef references an identity by ID
+type identityRef struct {
+	ID string `json:"id"`
+}
+
+// updatePullRequest applies an update to a pull request and returns the result
+func (c *Client) updatePullRequest(ctx context.Context, project, repository string, prID int, update pullRequestUpdate) (PullRequest, error) {
+	url := c.urls.API(project, repositoryPath(repository, fmt.Sprintf("pullRequests/%d", prID)))
+
+	var pr PullRequest
+	if err := c.doJSON(ctx, http.MethodPatch, url, update, &pr); err != nil {
+		return PullRequest{}, err
+	}
+
+	return pr, nil
+}
+
+// CompletePullRequest completes a pull request now with the given options.
+// sourceCommitID is the source commit the user reviewed, normally the PR's
+// LastMergeSourceCommit as loaded; the server rejects the completion when the
+// source branch has moved on since.
+func (c *Client) CompletePullRequest(ctx context.Context, project, repository string, prID int, sourceCommitID string, options CompletionOptions) (PullRequest, error) {
+	if sourceCommitID == "" {
+		return PullRequest{}, fmt.Errorf("failed to complete pull request: source commit is unknown, refresh and try again")
+	}
+
+	pr, err := c.updatePullRequest(ctx, project, repository, prID, pullRequestUpdate{
+		Status:                "completed",
+		LastMergeSourceCommit: &CommitRef{CommitID: sourceCommitID},
+		CompletionOptions:     &options,
+	})
+	if err != nil {
+		return PullRequest{}, fmt.Errorf("failed to complete pull request: %w", err)
+	}
+
+	return pr, nil
+}
+
+// SetAutoComplete enables auto-complete on behalf of a user with the given
+// options, so the PR completes once all policies pass
+func (c *Client) SetAutoComplete(ctx context.Context, project, repository string, prID int, userID string, options CompletionOptions) (PullRequest, error) {
+	pr, err := c.updatePullRequest(ctx, project, repository, prID, pullRequestUpdate{
+		AutoCompleteSetBy: &identityRef{ID: userID},
+		CompletionOptions: &options,
+	})
+	if err != nil {
+		return PullRequest{}, fmt.Errorf("failed to set auto-complete: %w", err)
+	}
+
+	return pr, nil
+}
+
+// CancelAutoComplete turns off auto-complete for a pull request
+func (c *Client) CancelAutoComplete(ctx context.Context, project, repository string, prID int) (PullRequest, error) {
+	pr, err := c.updatePullRequest(ctx, project, repository, prID, pullRequestUpdate{
+		AutoCompleteSetBy: &identityRef{ID: emptyIdentityID},
+	})
+	if err != nil {
+		return PullRequest{}, fmt.Errorf("failed to cancel auto-complete: %w", err)
+	}
+
+	return pr, nil
+}
+
+// AbandonPullRequest abandons a pull request
+func (c *Client) AbandonPullRequest(ctx context.Context, project, repository string, prID int) (PullRequest, error) {
+	pr, err := c.updatePullRequest(ctx, project, repository, prID, pullRequestUpdate{Status: "abandoned"})
+	if err != nil {
+		return PullRequest{}, fmt.Errorf("failed to abandon pull request: %w", err)
+	}
+
+	return pr, nil
+}
+
+// PublishPullRequest publishes a draft pull request, making it ready for review
+func (c *Client) PublishPullRequest(ctx context.Context, project, repository string, prID int) (PullRequest, error) {
+	isDraft := false
+	pr, err := c.updatePullRequest(ctx, project, repository, prID, pullRequestUpdate{IsDraft: &isDraft})
+	if err != nil {
+		return PullRequest{}, fmt.Errorf("failed to publish pull request: %w", err)
+	}
+
+	return pr, nil
+}
@@ -0,0 +1,149 @@
+package azuredevops
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried
+type RetryPolicy struct {
+	MaxRetries int           // Number of retries after the first attempt
+	BaseDelay  time.Duration // Delay before the first retry, doubled on each attempt
+	MaxDelay   time.Duration // Upper bound for a single backoff delay
+}
+
+// DefaultRetryPolicy returns the retry policy used by new clients
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: 3,
+		BaseDelay:  time.Second,
+		MaxDelay:   30 * time.Second,
+	}
+}
+
+// backoff returns the delay before retry number attempt (starting at 0).
+// It uses exponential backoff with jitter so concurrent clients spread out.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << attempt
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	// Pick a random delay between half and the full backoff
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// isRetryableStatus reports whether a response status indicates a transient failure
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// ThrottleState describes the rate limiting Azure DevOps has reported for the client
+type ThrottleState struct {
+	DelayedUntil time.Time // Requests are being held back until this time
+	Resource     string    // The throttled resource, from X-RateLimit-Resource
+	Limit        int       // Quota size from X-RateLimit-Limit, -1 if unknown
+	Remaining    int       // Remaining quota from X-RateLimit-Remaining, -1 if unknown
+}
+
+// Throttled reports whether requests are currently being delayed
+func (s ThrottleState) Throttled() bool {
+	return time.Now().Before(s.DelayedUntil)
+}
+
+// ThrottleState returns the most recent rate limiting state
+func (c *Client) ThrottleState() ThrottleState {
+	c.throttleMu.Lock()
+	defer c.throttleMu.Unlock()
+	return c.throttle
+}
+
+// recordRateLimit updates the throttle state from the X-RateLimit-* response headers
+func (c *Client) recordRateLimit(header http.Header) {
+	c.throttleMu.Lock()
+	defer c.throttleMu.Unlock()
+
+	// Most responses carry no rate limit headers; keep the last reported quota
+	// rather than flickering back to unknown
+	if resource := header.Get("X-RateLimit-Resource"); resource != "" {
+		c.throttle.Resource = resource
+	}
+	if limit := headerInt(header, "X-RateLimit-Limit"); limit >= 0 {
+		c.throttle.Limit = limit
+	}
+	if remaining := headerInt(header, "X-RateLimit-Remaining"); remaining >= 0 {
+		c.throttle.Remaining = remaining
+	}
+
+	// X-RateLimit-Delay reports how long Azure DevOps held this request back
+	if delay, err := strconv.ParseFloat(header.Get("X-RateLimit-Delay"), 64); err == nil && delay > 0 {
+		c.delayUntil(time.Now().Add(time.Duration(delay * float64(time.Second))))
+	}
+}
+
+// delayUntil extends the throttle window; the caller must hold throttleMu
+func (c *Client) delayUntil(t time.Time) {
+	if t.After(c.throttle.DelayedUntil) {
+		c.throttle.DelayedUntil = t
+	}
+}
+
+// retryDelay returns how long to wait before retrying, preferring the server's
+// Retry-After header over the client's own backoff. Only the backoff is capped
+// at the policy's MaxDelay; retrying before the server's delay has passed
+// would just be throttled again.
+func (c *Client) retryDelay(attempt int, header http.Header) time.Duration {
+	delay := c.retry.backoff(attempt)
+	if header != nil {
+		if retryAfter, ok := parseRetryAfter(header.Get("Retry-After")); ok {
+			delay = retryAfter
+		}
+	}
+
+	c.throttleMu.Lock()
+	c.delayUntil(time.Now().Add(delay))
+	c.throttleMu.Unlock()
+
+	return delay
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		delay := time.Until(t)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
+
+// headerInt parses an integer header, returning -1 when it is missing or invalid
+func headerInt(header http.Header, key string) int {
+	value, err := strconv.ParseFloat(header.Get(key), 64)
+	if err != nil {
+		return -1
+	}
+	return int(value)
+}
@@ -0,0 +1,141 @@
+package azuredevops
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "missing", value: ""},
+		{name: "seconds", value: "5", want: 5 * time.Second, wantOK: true},
+		{name: "zero seconds", value: "0", want: 0, wantOK: true},
+		{name: "negative seconds", value: "-1"},
+		{name: "invalid", value: "soon"},
+		{name: "past date", value: "Mon, 02 Jan 2006 15:04:05 GMT", want: 0, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfterFutureDate(t *testing.T) {
+	value := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+
+	got, ok := parseRetryAfter(value)
+	// HTTP dates have whole seconds
+	if !ok || got <= 58*time.Second || got > time.Minute {
+		t.Errorf("parseRetryAfter(%q) = %v, %v, want about a minute", value, got, ok)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 3, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	limits := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second, 5 * time.Second}
+
+	for attempt, limit := range limits {
+		for i := 0; i < 100; i++ {
+			if got := policy.backoff(attempt); got < limit/2 || got > limit {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", attempt, got, limit/2, limit)
+			}
+		}
+	}
+
+	// A shift past the size of a duration must not overflow into no delay
+	if got := policy.backoff(100); got < policy.MaxDelay/2 || got > policy.MaxDelay {
+		t.Errorf("backoff(100) = %v, want between %v and %v", got, policy.MaxDelay/2, policy.MaxDelay)
+	}
+}
+
+func TestRecordRateLimit(t *testing.T) {
+	c := NewClient("https://dev.azure.com/org", DefaultAPIVersion, "pat")
+
+	if state := c.ThrottleState(); state.Limit != -1 || state.Remaining != -1 || state.Throttled() {
+		t.Fatalf("initial state = %+v, want unknown quota and not throttled", state)
+	}
+
+	header := http.Header{}
+	header.Set("X-RateLimit-Resource", "Core")
+	header.Set("X-RateLimit-Limit", "200")
+	header.Set("X-RateLimit-Remaining", "12.5")
+	header.Set("X-RateLimit-Delay", "2")
+	c.recordRateLimit(header)
+
+	state := c.ThrottleState()
+	if state.Resource != "Core" || state.Limit != 200 || state.Remaining != 12 {
+		t.Errorf("state = %+v, want resource Core, limit 200, remaining 12", state)
+	}
+	if !state.Throttled() {
+		t.Errorf("state is not throttled after X-RateLimit-Delay")
+	}
+
+	// Responses without rate limit headers keep the last reported quota
+	c.recordRateLimit(http.Header{})
+	if got := c.ThrottleState(); got.Resource != "Core" || got.Limit != 200 || got.Remaining != 12 {
+		t.Errorf("state after response without headers = %+v, want it unchanged", got)
+	}
+}
+
+func TestRetryDelayHonoursRetryAfter(t *testing.T) {
+	c := NewClient("https://dev.azure.com/org", DefaultAPIVersion, "pat")
+
+	header := http.Header{}
+	header.Set("Retry-After", "60")
+	if got := c.retryDelay(0, header); got != time.Minute {
+		t.Errorf("retryDelay() = %v, want the server's 1m0s", got)
+	}
+	if until := time.Until(c.ThrottleState().DelayedUntil); until < 59*time.Second {
+		t.Errorf("delayed for %v, want about a minute", until)
+	}
+}
+
+func TestRequestRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		status       int
+		wantAttempts int
+	}{
+		{name: "read on server error", method: http.MethodGet, status: http.StatusServiceUnavailable, wantAttempts: 3},
+		{name: "read when throttled", method: http.MethodGet, status: http.StatusTooManyRequests, wantAttempts: 3},
+		{name: "read on client error", method: http.MethodGet, status: http.StatusNotFound, wantAttempts: 1},
+		{name: "patch on server error", method: http.MethodPatch, status: http.StatusInternalServerError, wantAttempts: 1},
+		{name: "put on server error", method: http.MethodPut, status: http.StatusBadGateway, wantAttempts: 1},
+		{name: "post when throttled", method: http.MethodPost, status: http.StatusTooManyRequests, wantAttempts: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			c := NewClient(server.URL, DefaultAPIVersion, "pat")
+			c.retry = RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+			if _, _, err := c.request(context.Background(), tt.method, server.URL, []byte("{}")); err == nil {
+				t.Fatalf("request() succeeded, want an error")
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("request() made %d attempts, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
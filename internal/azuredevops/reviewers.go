@@ -0,0 +1,157 @@
+package azuredevops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Reviewer votes on a pull request
+const (
+	VoteApproved                = 10
+	VoteApprovedWithSuggestions = 5
+	VoteNone                    = 0
+	VoteWaitingForAuthor        = -5
+	VoteRejected                = -10
+)
+
+// Reviewer represents a reviewer of a pull request and their vote
+type Reviewer struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	UniqueName  string `json:"uniqueName"`
+	Vote        int    `json:"vote"`
+	IsRequired  bool   `json:"isRequired"`
+	HasDeclined bool   `json:"hasDeclined"`
+	IsContainer bool   `json:"isContainer"` // The reviewer is a group or team
+	// VotedFor lists the groups a user's vote counts for, or the members
+	// whose votes make up a group's vote
+	VotedFor []Reviewer `json:"votedFor"`
+}
+
+// VoteSummary counts the votes of a pull request's reviewers
+type VoteSummary struct {
+	Approved         int // Approved, with or without suggestions
+	WaitingForAuthor int
+	Rejected         int
+	NoVote           int
+	RequiredPending  int // Required reviewers that haven't approved
+}
+
+// VoteSummary counts the votes of the PR's reviewers. A group's vote is made
+// up of its members' votes, which are already counted, so groups only count
+// when they are required and haven't been approved.
+func (pr PullRequest) VoteSummary() VoteSummary {
+	var summary VoteSummary
+	for _, reviewer := range pr.Reviewers {
+		if reviewer.IsContainer {
+			if reviewer.IsRequired && reviewer.Vote <= VoteNone {
+				summary.RequiredPending++
+			}
+			continue
+		}
+
+		switch {
+		case reviewer.Vote > VoteNone:
+			summary.Approved++
+		case reviewer.Vote == VoteWaitingForAuthor:
+			summary.WaitingForAuthor++
+		case reviewer.Vote == VoteRejected:
+			summary.Rejected++
+		default:
+			summary.NoVote++
+		}
+		if reviewer.IsRequired && reviewer.Vote <= VoteNone {
+			summary.RequiredPending++
+		}
+	}
+	return summary
+}
+
+// VoteLabel returns a human readable name for a vote
+func VoteLabel(vote int) string {
+	switch vote {
+	case VoteApproved:
+		return "Approved"
+	case VoteApprovedWithSuggestions:
+		return "Approved with suggestions"
+	case VoteWaitingForAuthor:
+		return "Waiting for author"
+	case VoteRejected:
+		return "Rejected"
+	}
+	return "No vote"
+}
+
+// Reviewer returns the reviewer with the given ID, if they are a reviewer of the PR
+func (pr PullRequest) Reviewer(id string) (Reviewer, bool) {
+	for _, reviewer := range pr.Reviewers {
+		if reviewer.ID == id {
+			return reviewer, true
+		}
+	}
+	return Reviewer{}, false
+}
+
+// Identity is the user the client is authenticated as
+type Identity struct {
+	ID          string
+	DisplayName string
+}
+
+// CurrentUser returns the user the client's token belongs to. The result is
+// cached after the first successful lookup. The lock isn't held during the
+// lookup, so a slow or throttled one doesn't hold up callers that give up;
+// concurrent first callers may each look the user up.
+func (c *Client) CurrentUser(ctx context.Context) (Identity, error) {
+	c.identityMu.Lock()
+	identity := c.identity
+	c.identityMu.Unlock()
+	if identity != nil {
+		return *identity, nil
+	}
+
+	body, err := c.doRequest(ctx, c.urls.ConnectionData())
+	if err != nil {
+		return Identity{}, fmt.Errorf("failed to get connection data: %w", err)
+	}
+
+	var data struct {
+		AuthenticatedUser struct {
+			ID                  string `json:"id"`
+			ProviderDisplayName string `json:"providerDisplayName"`
+		} `json:"authenticatedUser"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return Identity{}, fmt.Errorf("failed to parse connection data: %w", err)
+	}
+
+	identity = &Identity{
+		ID:          data.AuthenticatedUser.ID,
+		DisplayName: data.AuthenticatedUser.ProviderDisplayName,
+	}
+
+	c.identityMu.Lock()
+	c.identity = identity
+	c.identityMu.Unlock()
+
+	return *identity, nil
+}
+
+// SetVote casts a reviewer's vote on a pull request, adding them as a
+// reviewer if they aren't one yet
+func (c *Client) SetVote(ctx context.Context, project, repository string, prID int, reviewerID string, vote int) (Reviewer, error) {
+	url := c.urls.API(project, repositoryPath(repository, fmt.Sprintf("pullRequests/%d/reviewers/%s", prID, reviewerID)))
+
+	request := struct {
+		Vote int `json:"vote"`
+	}{Vote: vote}
+
+	var reviewer Reviewer
+	if err := c.doJSON(ctx, http.MethodPut, url, request, &reviewer); err != nil {
+		return Reviewer{}, fmt.Errorf("failed to set vote: %w", err)
+	}
+
+	return reviewer, nil
+}
@@ -0,0 +1,89 @@
+package azuredevops
+
+import "testing"
+
+func TestVoteSummary(t *testing.T) {
+	member := func(vote int, groups ...Reviewer) Reviewer {
+		return Reviewer{Vote: vote, VotedFor: groups}
+	}
+	group := func(vote int, required bool, members ...Reviewer) Reviewer {
+		return Reviewer{Vote: vote, IsRequired: required, IsContainer: true, VotedFor: members}
+	}
+
+	tests := []struct {
+		name      string
+		reviewers []Reviewer
+		want      VoteSummary
+	}{
+		{
+			name: "no reviewers",
+		},
+		{
+			name: "user votes",
+			reviewers: []Reviewer{
+				member(VoteApproved),
+				member(VoteApprovedWithSuggestions),
+				member(VoteWaitingForAuthor),
+				member(VoteRejected),
+				member(VoteNone),
+			},
+			want: VoteSummary{Approved: 2, WaitingForAuthor: 1, Rejected: 1, NoVote: 1},
+		},
+		{
+			name: "group counts only its members' votes",
+			reviewers: []Reviewer{
+				member(VoteApproved, group(VoteApproved, false)),
+				group(VoteApproved, false, member(VoteApproved)),
+			},
+			want: VoteSummary{Approved: 1},
+		},
+		{
+			name: "optional group without votes",
+			reviewers: []Reviewer{
+				group(VoteNone, false),
+			},
+			want: VoteSummary{},
+		},
+		{
+			name: "required group without votes is pending",
+			reviewers: []Reviewer{
+				group(VoteNone, true),
+			},
+			want: VoteSummary{RequiredPending: 1},
+		},
+		{
+			name: "required group approved by a member",
+			reviewers: []Reviewer{
+				member(VoteApproved, group(VoteApproved, true)),
+				group(VoteApproved, true, member(VoteApproved)),
+			},
+			want: VoteSummary{Approved: 1},
+		},
+		{
+			name: "required group waiting on a member",
+			reviewers: []Reviewer{
+				member(VoteWaitingForAuthor, group(VoteWaitingForAuthor, true)),
+				group(VoteWaitingForAuthor, true, member(VoteWaitingForAuthor)),
+			},
+			want: VoteSummary{WaitingForAuthor: 1, RequiredPending: 1},
+		},
+		{
+			name: "required users",
+			reviewers: []Reviewer{
+				{Vote: VoteApproved, IsRequired: true},
+				{Vote: VoteNone, IsRequired: true},
+				{Vote: VoteRejected, IsRequired: true},
+			},
+			want: VoteSummary{Approved: 1, NoVote: 1, Rejected: 1, RequiredPending: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := PullRequest{Reviewers: tt.reviewers}
+			if got := pr.VoteSummary(); got != tt.want {
+				t.Errorf("VoteSummary() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
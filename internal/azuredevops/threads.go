@@ -0,0 +1,258 @@
+package azuredevops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Thread statuses accepted by the threads API
+const (
+	ThreadStatusActive   = "active"
+	ThreadStatusFixed    = "fixed"
+	ThreadStatusWontFix  = "wontFix"
+	ThreadStatusClosed   = "closed"
+	ThreadStatusByDesign = "byDesign"
+	ThreadStatusPending  = "pending"
+)
+
+// Comment types of thread comments
+const (
+	CommentTypeText   = "text"
+	CommentTypeSystem = "system"
+)
+
+// PRThread represents a comment thread on a pull request
+type PRThread struct {
+	ID              int                       `json:"id"`
+	Status          string                    `json:"status"`
+	PublishedDate   time.Time                 `json:"publishedDate"`
+	LastUpdatedDate time.Time                 `json:"lastUpdatedDate"`
+	ThreadContext   *ThreadContext            `json:"threadContext"` // nil for threads on the PR as a whole
+	Comments        []PRComment               `json:"comments"`
+	IsDeleted       bool                      `json:"isDeleted"`
+	Properties      map[string]threadProperty `json:"properties"`
+}
+
+// threadProperty is a typed value attached to a thread by Azure DevOps
+type threadProperty struct {
+	Value any `json:"$value"`
+}
+
+// ThreadContext locates a thread in a file. Right positions refer to the PR
+// version of the file and left positions to the base version.
+type ThreadContext struct {
+	FilePath       string        `json:"filePath"`
+	RightFileStart *FilePosition `json:"rightFileStart,omitempty"`
+	RightFileEnd   *FilePosition `json:"rightFileEnd,omitempty"`
+	LeftFileStart  *FilePosition `json:"leftFileStart,omitempty"`
+	LeftFileEnd    *FilePosition `json:"leftFileEnd,omitempty"`
+}
+
+// PRThreadContext ties a new inline thread to the file change and iterations
+// it was written on, so Azure DevOps can track its position across pushes
+type PRThreadContext struct {
+	ChangeTrackingID int              `json:"changeTrackingId"`
+	IterationContext IterationContext `json:"iterationContext"`
+}
+
+// IterationContext names the two iterations of a PR diff. Iteration 1 as the
+// first side compares against the target branch.
+type IterationContext struct {
+	FirstComparingIteration  int `json:"firstComparingIteration"`
+	SecondComparingIteration int `json:"secondComparingIteration"`
+}
+
+// FilePosition is a 1-based line and character offset in a file
+type FilePosition struct {
+	Line   int `json:"line"`
+	Offset int `json:"offset"`
+}
+
+// newComment is the request body of a new comment
+type newComment struct {
+	ParentCommentID int    `json:"parentCommentId"`
+	Content         string `json:"content"`
+	CommentType     string `json:"commentType"`
+}
+
+// PRComment represents a comment in a thread
+type PRComment struct {
+	ID              int       `json:"id"`
+	ParentCommentID int       `json:"parentCommentId"`
+	Author          User      `json:"author"`
+	Content         string    `json:"content"`
+	PublishedDate   time.Time `json:"publishedDate"`
+	CommentType     string    `json:"commentType"`
+	IsDeleted       bool      `json:"isDeleted"`
+}
+
+// IsSystem reports whether the thread only holds system generated comments,
+// such as vote changes and pushed updates
+func (t PRThread) IsSystem() bool {
+	for _, comment := range t.Comments {
+		if comment.CommentType != CommentTypeSystem {
+			return false
+		}
+	}
+	return true
+}
+
+// IsResolved reports whether the thread no longer needs attention
+func (t PRThread) IsResolved() bool {
+	return t.Status != "" && t.Status != ThreadStatusActive && t.Status != ThreadStatusPending
+}
+
+// FilePath returns the path of the file the thread is on, or empty for
+// threads on the PR as a whole
+func (t PRThread) FilePath() string {
+	if t.ThreadContext == nil {
+		return ""
+	}
+	return t.ThreadContext.FilePath
+}
+
+// Property returns a property of the thread as a string, or empty when unset
+func (t PRThread) Property(name string) string {
+	property, ok := t.Properties[name]
+	if !ok || property.Value == nil {
+		return ""
+	}
+	return fmt.Sprint(property.Value)
+}
+
+// LastVote returns the latest vote a user cast on a pull request, taken from
+// the system threads Azure DevOps adds for every vote. A reviewer whose last
+// vote isn't VoteNone but who currently hasn't voted had their vote reset,
+// e.g. by a push to the source branch.
+func LastVote(threads []PRThread, userID string) (int, bool) {
+	var latest *PRThread
+	for i, thread := range threads {
+		if thread.Property("CodeReviewThreadType") != "VoteUpdate" || len(thread.Comments) == 0 {
+			continue
+		}
+		if thread.Comments[0].Author.ID != userID {
+			continue
+		}
+		if latest == nil || thread.PublishedDate.After(latest.PublishedDate) {
+			latest = &threads[i]
+		}
+	}
+	if latest == nil {
+		return VoteNone, false
+	}
+
+	vote, err := strconv.Atoi(latest.Property("CodeReviewVoteResult"))
+	if err != nil {
+		return VoteNone, false
+	}
+	return vote, true
+}
+
+// GetPRThreads fetches the comment threads of a pull request, leaving out
+// deleted threads and comments. When iteration is greater than 0 inline threads
+// are positioned in the diff between the target branch and that iteration,
+// rather than on the lines they were first written on.
+func (c *Client) GetPRThreads(ctx context.Context, project, repository string, prID, iteration int) ([]PRThread, error) {
+	path := fmt.Sprintf("pullRequests/%d/threads", prID)
+	if iteration > 0 {
+		path += fmt.Sprintf("?$iteration=%d&$baseIteration=0", iteration)
+	}
+	url := c.urls.API(project, repositoryPath(repository, path))
+
+	body, err := c.doRequest(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PR threads: %w", err)
+	}
+
+	var response listResponse[PRThread]
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse PR threads: %w", err)
+	}
+
+	var threads []PRThread
+	for _, thread := range response.Value {
+		if thread.IsDeleted {
+			continue
+		}
+
+		var comments []PRComment
+		for _, comment := range thread.Comments {
+			if !comment.IsDeleted {
+				comments = append(comments, comment)
+			}
+		}
+		if len(comments) == 0 {
+			continue
+		}
+		thread.Comments = comments
+		threads = append(threads, thread)
+	}
+
+	return threads, nil
+}
+
+// CreatePRThread starts a new thread on a pull request. A nil context starts a
+// thread on the PR as a whole. prContext ties an inline thread to the iteration
+// it was written on and may be nil for general threads.
+func (c *Client) CreatePRThread(ctx context.Context, project, repository string, prID int, threadContext *ThreadContext, prContext *PRThreadContext, content string) (PRThread, error) {
+	url := c.urls.API(project, repositoryPath(repository, fmt.Sprintf("pullRequests/%d/threads", prID)))
+
+	request := struct {
+		Comments                 []newComment     `json:"comments"`
+		Status                   string           `json:"status"`
+		ThreadContext            *ThreadContext   `json:"threadContext,omitempty"`
+		PullRequestThreadContext *PRThreadContext `json:"pullRequestThreadContext,omitempty"`
+	}{
+		Comments:                 []newComment{{Content: content, CommentType: CommentTypeText}},
+		Status:                   ThreadStatusActive,
+		ThreadContext:            threadContext,
+		PullRequestThreadContext: prContext,
+	}
+
+	var thread PRThread
+	if err := c.doJSON(ctx, http.MethodPost, url, request, &thread); err != nil {
+		return PRThread{}, fmt.Errorf("failed to create thread: %w", err)
+	}
+
+	return thread, nil
+}
+
+// ReplyToPRThread adds a comment to a thread, in reply to parentCommentID,
+// which is normally the thread's first comment that hasn't been deleted
+func (c *Client) ReplyToPRThread(ctx context.Context, project, repository string, prID, threadID, parentCommentID int, content string) (PRComment, error) {
+	url := c.urls.API(project, repositoryPath(repository, fmt.Sprintf("pullRequests/%d/threads/%d/comments", prID, threadID)))
+
+	request := newComment{
+		ParentCommentID: parentCommentID,
+		Content:         content,
+		CommentType:     CommentTypeText,
+	}
+
+	var comment PRComment
+	if err := c.doJSON(ctx, http.MethodPost, url, request, &comment); err != nil {
+		return PRComment{}, fmt.Errorf("failed to reply to thread: %w", err)
+	}
+
+	return comment, nil
+}
+
+// SetPRThreadStatus changes the status of a thread, e.g. to ThreadStatusFixed
+// to resolve it or ThreadStatusActive to reactivate it
+func (c *Client) SetPRThreadStatus(ctx context.Context, project, repository string, prID, threadID int, status string) (PRThread, error) {
+	url := c.urls.API(project, repositoryPath(repository, fmt.Sprintf("pullRequests/%d/threads/%d", prID, threadID)))
+
+	request := struct {
+		Status string `json:"status"`
+	}{Status: status}
+
+	var thread PRThread
+	if err := c.doJSON(ctx, http.MethodPatch, url, request, &thread); err != nil {
+		return PRThread{}, fmt.Errorf("failed to update thread status: %w", err)
+	}
+
+	return thread, nil
+}
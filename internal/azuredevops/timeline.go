@@ -0,0 +1,143 @@
+package azuredevops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Timeline record types shown in the build details
+const (
+	RecordTypeStage = "Stage"
+	RecordTypePhase = "Phase"
+	RecordTypeJob   = "Job"
+	RecordTypeTask  = "Task"
+)
+
+// Timeline record states
+const (
+	RecordStatePending    = "pending"
+	RecordStateInProgress = "inProgress"
+	RecordStateCompleted  = "completed"
+)
+
+// TimelineRecord is a stage, job, task or other step of a build
+type TimelineRecord struct {
+	ID           string       `json:"id"`
+	ParentID     string       `json:"parentId"`
+	Type         string       `json:"type"`
+	Name         string       `json:"name"`
+	Order        int          `json:"order"`
+	State        string       `json:"state"`
+	Result       string       `json:"result"`
+	StartTime    *time.Time   `json:"startTime"`
+	FinishTime   *time.Time   `json:"finishTime"`
+	ErrorCount   int          `json:"errorCount"`
+	WarningCount int          `json:"warningCount"`
+	Log          *TimelineLog `json:"log"` // nil for records without a log of their own
+	Issues       []Issue      `json:"issues"`
+}
+
+// TimelineLog references the log of a timeline record
+type TimelineLog struct {
+	ID int `json:"id"`
+}
+
+// Issue is an error or warning reported by a timeline record
+type Issue struct {
+	Type    string `json:"type"` // "error" or "warning"
+	Message string `json:"message"`
+}
+
+// Duration returns how long the record ran, up to now if it is still running,
+// or 0 if it hasn't started
+func (r TimelineRecord) Duration() time.Duration {
+	if r.StartTime == nil {
+		return 0
+	}
+	end := time.Now()
+	if r.FinishTime != nil {
+		end = *r.FinishTime
+	}
+	return end.Sub(*r.StartTime)
+}
+
+// TimelineNode is a timeline record with its child records
+type TimelineNode struct {
+	Record   TimelineRecord
+	Children []*TimelineNode
+}
+
+// TimelineTree arranges timeline records into a stage → job → task tree in
+// execution order. Phases only group jobs, so their jobs are attached to the
+// phase's stage. Builds without stages have jobs at the top level.
+func TimelineTree(records []TimelineRecord) []*TimelineNode {
+	nodes := make(map[string]*TimelineNode, len(records))
+	for _, record := range records {
+		nodes[record.ID] = &TimelineNode{Record: record}
+	}
+
+	// parent returns the closest ancestor that is shown, or nil for the root
+	parent := func(record TimelineRecord) *TimelineNode {
+		for {
+			node, ok := nodes[record.ParentID]
+			if !ok {
+				return nil
+			}
+			if node.Record.Type != RecordTypePhase {
+				return node
+			}
+			record = node.Record
+		}
+	}
+
+	var roots []*TimelineNode
+	for _, record := range records {
+		switch record.Type {
+		case RecordTypeStage, RecordTypeJob, RecordTypeTask:
+		default:
+			continue // Phases, checkpoints and other bookkeeping records
+		}
+
+		node := nodes[record.ID]
+		if p := parent(record); p != nil {
+			p.Children = append(p.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+
+	sortTimeline(roots)
+	return roots
+}
+
+// sortTimeline sorts timeline nodes and their children by execution order
+func sortTimeline(nodes []*TimelineNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].Record.Order < nodes[j].Record.Order
+	})
+	for _, node := range nodes {
+		sortTimeline(node.Children)
+	}
+}
+
+// GetBuildTimeline fetches the timeline records of a build
+func (c *Client) GetBuildTimeline(ctx context.Context, project string, buildID int) ([]TimelineRecord, error) {
+	url := c.urls.API(project, fmt.Sprintf("build/builds/%d/timeline", buildID))
+
+	body, err := c.doRequest(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get build timeline: %w", err)
+	}
+
+	var timeline struct {
+		Records []TimelineRecord `json:"records"`
+	}
+	if err := json.Unmarshal(body, &timeline); err != nil {
+		return nil, fmt.Errorf("failed to parse build timeline: %w", err)
+	}
+
+	return timeline.Records, nil
+}
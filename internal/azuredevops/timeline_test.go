@@ -0,0 +1,55 @@
+package azuredevops
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTimelineTree(t *testing.T) {
+	records := []TimelineRecord{
+		{ID: "task2", ParentID: "job1", Type: RecordTypeTask, Name: "Test", Order: 2},
+		{ID: "stage2", Type: RecordTypeStage, Name: "Deploy", Order: 2},
+		{ID: "phase1", ParentID: "stage1", Type: RecordTypePhase, Name: "Phase", Order: 1},
+		{ID: "job1", ParentID: "phase1", Type: RecordTypeJob, Name: "Build job", Order: 1},
+		{ID: "task1", ParentID: "job1", Type: RecordTypeTask, Name: "Compile", Order: 1},
+		{ID: "stage1", Type: RecordTypeStage, Name: "Build", Order: 1},
+		{ID: "checkpoint", ParentID: "stage2", Type: "Checkpoint", Name: "Approval", Order: 1},
+		{ID: "job2", ParentID: "stage2", Type: RecordTypeJob, Name: "Deploy job", Order: 2},
+	}
+
+	// names flattens the tree into indented names in display order
+	var names func(nodes []*TimelineNode, indent string) []string
+	names = func(nodes []*TimelineNode, indent string) []string {
+		var result []string
+		for _, node := range nodes {
+			result = append(result, indent+node.Record.Name)
+			result = append(result, names(node.Children, indent+"  ")...)
+		}
+		return result
+	}
+
+	want := []string{
+		"Build",
+		"  Build job",
+		"    Compile",
+		"    Test",
+		"Deploy",
+		"  Deploy job",
+	}
+	if got := names(TimelineTree(records), ""); !reflect.DeepEqual(got, want) {
+		t.Errorf("TimelineTree() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestTimelineTreeWithoutStages(t *testing.T) {
+	records := []TimelineRecord{
+		{ID: "phase", Type: RecordTypePhase, Name: "Phase"},
+		{ID: "job2", ParentID: "phase", Type: RecordTypeJob, Name: "Second", Order: 2},
+		{ID: "job1", ParentID: "phase", Type: RecordTypeJob, Name: "First", Order: 1},
+	}
+
+	roots := TimelineTree(records)
+	if len(roots) != 2 || roots[0].Record.Name != "First" || roots[1].Record.Name != "Second" {
+		t.Errorf("TimelineTree() roots = %v, want the jobs First and Second", roots)
+	}
+}
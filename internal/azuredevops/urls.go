@@ -0,0 +1,111 @@
+package azuredevops
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	// DefaultServerURL is the Azure DevOps Services host
+	DefaultServerURL = "https://dev.azure.com"
+
+	// DefaultAPIVersion is the REST API version used when none is configured
+	DefaultAPIVersion = "7.1"
+)
+
+// URLBuilder generates REST API, web and clone URLs for an organization or collection.
+// All URLs are rooted at the collection URL, which is https://dev.azure.com/{organization}
+// for Azure DevOps Services, https://{organization}.visualstudio.com for legacy
+// organizations and {server}/{collection} for Azure DevOps Server.
+type URLBuilder struct {
+	collectionURL string
+	apiVersion    string
+}
+
+// NewURLBuilder creates a URL builder for the given collection URL and API version
+func NewURLBuilder(collectionURL, apiVersion string) URLBuilder {
+	if apiVersion == "" {
+		apiVersion = DefaultAPIVersion
+	}
+
+	return URLBuilder{
+		collectionURL: strings.TrimRight(collectionURL, "/"),
+		apiVersion:    apiVersion,
+	}
+}
+
+// CollectionURL builds the collection URL from its parts. When serverURL is empty
+// the Azure DevOps Services host is used with the organization as the collection.
+func CollectionURL(serverURL, collection, organization string) string {
+	if serverURL == "" {
+		return fmt.Sprintf("%s/%s", DefaultServerURL, url.PathEscape(organization))
+	}
+
+	collectionURL := strings.TrimRight(serverURL, "/")
+	if collection != "" {
+		collectionURL += "/" + url.PathEscape(collection)
+	}
+
+	return collectionURL
+}
+
+// API returns the URL of a project-scoped REST API resource. The path is relative
+// to _apis and may include a query string; the api-version parameter is appended.
+func (b URLBuilder) API(project, path string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
+	return fmt.Sprintf("%s/%s/_apis/%s%sapi-version=%s",
+		b.collectionURL, url.PathEscape(project), path, separator, b.apiVersion)
+}
+
+// CollectionAPI returns the URL of a REST API resource that isn't scoped to a
+// project, such as the list of projects
+func (b URLBuilder) CollectionAPI(path string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
+	return fmt.Sprintf("%s/_apis/%s%sapi-version=%s", b.collectionURL, path, separator, b.apiVersion)
+}
+
+// repositoryPath returns the API path of a Git repository resource, relative to
+// _apis, with the repository name escaped
+func repositoryPath(repository, path string) string {
+	return fmt.Sprintf("git/repositories/%s/%s", url.PathEscape(repository), path)
+}
+
+// PreviewAPI returns the URL of a project-scoped REST API resource that is
+// only available as a preview of the configured API version
+func (b URLBuilder) PreviewAPI(project, path string) string {
+	preview := b
+	preview.apiVersion = b.apiVersion + "-preview"
+	return preview.API(project, path)
+}
+
+// ConnectionData returns the URL of the connection data resource, which
+// describes the authenticated user
+func (b URLBuilder) ConnectionData() string {
+	return fmt.Sprintf("%s/_apis/connectionData", b.collectionURL)
+}
+
+// BuildResults returns the web URL of a build's results page
+func (b URLBuilder) BuildResults(project string, buildID int) string {
+	return fmt.Sprintf("%s/%s/_build/results?buildId=%d",
+		b.collectionURL, url.PathEscape(project), buildID)
+}
+
+// PullRequest returns the web URL of a pull request
+func (b URLBuilder) PullRequest(project, repository string, prID int) string {
+	return fmt.Sprintf("%s/pullrequest/%d", b.Repository(project, repository), prID)
+}
+
+// Repository returns the web URL of a repository, which is also its clone URL
+func (b URLBuilder) Repository(project, repository string) string {
+	return fmt.Sprintf("%s/%s/_git/%s",
+		b.collectionURL, url.PathEscape(project), url.PathEscape(repository))
+}
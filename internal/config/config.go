@@ -3,28 +3,53 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
+
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/azuredevops"
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/glob"
 )
 
 // PullRequestConfig represents a single pull request source
 type PullRequestConfig struct {
-	Project    string `json:"project"`
-	Repository string `json:"repository"`
+	Project        string   `json:"project"`        // Project name, or a glob such as "*" to query every project
+	Repository     string   `json:"repository"`     // Repository name or glob (optional, default every repository)
+	Limit          int      `json:"limit"`          // Maximum number of pull requests to load (0 = all)
+	TargetBranches []string `json:"targetBranches"` // Target branch names or globs, e.g. "main" or "release/*" (optional)
+	CreatedBy      string   `json:"createdBy"`      // Creator's email, display name, ID or "@me" (optional)
+	Reviewer       string   `json:"reviewer"`       // Reviewer's email, display name, team name, ID or "@me" (optional)
+	Labels         []string `json:"labels"`         // Only PRs with at least one of these labels (optional)
+	Drafts         string   `json:"drafts"`         // "include" (default), "exclude" or "only"
 }
 
+// Values of PullRequestConfig.Drafts
+const (
+	DraftsInclude = "include"
+	DraftsExclude = "exclude"
+	DraftsOnly    = "only"
+)
+
 // PipelineConfig represents a single pipeline source
 type PipelineConfig struct {
 	Project      string `json:"project"`
 	Pipeline     string `json:"pipeline"`     // Pipeline name (optional if DefinitionID is provided)
 	DefinitionID int    `json:"definitionId"` // Pipeline definition ID (optional if Pipeline is provided)
+	Limit        int    `json:"limit"`        // Maximum number of builds to load (default 10)
 }
 
 // Config represents the application configuration
 type Config struct {
-	Organization    string              `json:"organization"`
-	PullRequests    []PullRequestConfig `json:"pullRequests"`
-	Pipelines       []PipelineConfig    `json:"pipelines"`
-	RefreshInterval int                 `json:"refreshInterval"` // in seconds
+	Organization     string              `json:"organization"`
+	ServerURL        string              `json:"serverUrl"`  // Azure DevOps Server or legacy *.visualstudio.com URL (optional)
+	Collection       string              `json:"collection"` // Azure DevOps Server collection (optional)
+	APIVersion       string              `json:"apiVersion"` // REST API version (default 7.1)
+	PullRequests     []PullRequestConfig `json:"pullRequests"`
+	Pipelines        []PipelineConfig    `json:"pipelines"`
+	RefreshInterval  int                 `json:"refreshInterval"`  // in seconds
+	Concurrency      int                 `json:"concurrency"`      // Number of sources loaded in parallel (default 4)
+	DiffContextLines int                 `json:"diffContextLines"` // Unchanged lines shown around each diff change (default 3)
+	ExcludeFiles     []string            `json:"excludeFiles"`     // Glob patterns of PR files hidden from the changed files tree
 }
 
 // Load loads the configuration from a file
@@ -34,7 +59,10 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var cfg Config
+	// Defaults for fields where zero is a meaningful value
+	cfg := Config{
+		DiffContextLines: 3,
+	}
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
@@ -44,25 +72,83 @@ func Load(path string) (*Config, error) {
 		cfg.RefreshInterval = 30
 	}
 
+	// Set default concurrency if not specified
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 4
+	}
+
+	// Set default API version if not specified
+	if cfg.APIVersion == "" {
+		cfg.APIVersion = azuredevops.DefaultAPIVersion
+	}
+
+	// Set default build limit for pipelines that don't specify one
+	for i := range cfg.Pipelines {
+		if cfg.Pipelines[i].Limit == 0 {
+			cfg.Pipelines[i].Limit = 10
+		}
+	}
+
 	return &cfg, nil
 }
 
 // Validate validates the configuration
 func (c *Config) Validate() error {
-	if c.Organization == "" {
-		return fmt.Errorf("organization is required")
+	if c.Organization == "" && c.ServerURL == "" {
+		return fmt.Errorf("organization or serverUrl is required")
+	}
+
+	if c.ServerURL != "" {
+		u, err := url.Parse(c.ServerURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("serverUrl must be an absolute URL such as https://tfs.example.com/tfs")
+		}
 	}
 
 	if len(c.PullRequests) == 0 && len(c.Pipelines) == 0 {
 		return fmt.Errorf("at least one pull request or pipeline must be configured")
 	}
 
+	if c.DiffContextLines < 0 {
+		return fmt.Errorf("diffContextLines must not be negative")
+	}
+
+	for _, pattern := range c.ExcludeFiles {
+		if _, err := glob.Compile(pattern); err != nil {
+			return fmt.Errorf("excludeFiles: %w", err)
+		}
+	}
+
 	for i, pr := range c.PullRequests {
 		if pr.Project == "" {
 			return fmt.Errorf("pull request %d: project is required", i)
 		}
-		if pr.Repository == "" {
-			return fmt.Errorf("pull request %d: repository is required", i)
+		// Validate patterns with the same compile the dashboard matches them with
+		if _, err := glob.CompileIgnoreCase(pr.Project); err != nil {
+			return fmt.Errorf("pull request %d: project: %w", i, err)
+		}
+		if pr.Repository != "" {
+			if _, err := glob.CompileIgnoreCase(pr.Repository); err != nil {
+				return fmt.Errorf("pull request %d: repository: %w", i, err)
+			}
+		}
+		if pr.Limit < 0 {
+			return fmt.Errorf("pull request %d: limit must not be negative", i)
+		}
+		for _, branch := range pr.TargetBranches {
+			if _, err := glob.CompileName(strings.TrimPrefix(branch, "refs/heads/")); err != nil {
+				return fmt.Errorf("pull request %d: targetBranches: %w", i, err)
+			}
+		}
+		for _, label := range pr.Labels {
+			if strings.TrimSpace(label) == "" {
+				return fmt.Errorf("pull request %d: labels must not be empty", i)
+			}
+		}
+		switch pr.Drafts {
+		case "", DraftsInclude, DraftsExclude, DraftsOnly:
+		default:
+			return fmt.Errorf("pull request %d: drafts must be %q, %q or %q", i, DraftsInclude, DraftsExclude, DraftsOnly)
 		}
 	}
 
@@ -73,6 +159,9 @@ func (c *Config) Validate() error {
 		if p.Pipeline == "" && p.DefinitionID == 0 {
 			return fmt.Errorf("pipeline %d: either pipeline name or definitionId is required", i)
 		}
+		if p.Limit < 0 {
+			return fmt.Errorf("pipeline %d: limit must not be negative", i)
+		}
 	}
 
 	return nil
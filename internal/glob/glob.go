@@ -0,0 +1,167 @@
+// Package glob matches slash separated paths against glob patterns
+package glob
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Pattern is a compiled glob pattern.
+//
+// '*' matches any run of characters except '/', '?' matches a single character
+// except '/', and '**' matches across directories. "[abc]" and "[a-z]" match
+// one of the listed characters and "[!abc]" any other character except '/'.
+// A backslash makes the next character match itself. A pattern without a '/'
+// matches the last element of a path, so "*.cs" matches "src/app/Foo.cs".
+type Pattern struct {
+	source string
+	re     *regexp.Regexp
+}
+
+// Compile compiles a glob pattern
+func Compile(pattern string) (*Pattern, error) {
+	return compile(pattern, false, false)
+}
+
+// CompileName compiles a glob pattern that always has to match the whole
+// name, for slash separated names such as branches where "main" must not
+// match "feature/main"
+func CompileName(pattern string) (*Pattern, error) {
+	return compile(pattern, true, false)
+}
+
+// CompileIgnoreCase compiles a glob pattern that matches regardless of case,
+// for names such as projects and repositories that Azure DevOps compares
+// case-insensitively
+func CompileIgnoreCase(pattern string) (*Pattern, error) {
+	return compile(pattern, false, true)
+}
+
+func compile(pattern string, whole, ignoreCase bool) (*Pattern, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, fmt.Errorf("empty glob pattern")
+	}
+
+	source := pattern
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var expr strings.Builder
+	if ignoreCase {
+		expr.WriteString("(?i)")
+	}
+	expr.WriteString("^")
+	if !whole && !strings.Contains(pattern, "/") {
+		// Match the base name in any directory
+		expr.WriteString("(.*/)?")
+	}
+
+	// Walk runes rather than bytes so non-ASCII names stay intact
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '*':
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				i++
+				if i+1 < len(runes) && runes[i+1] == '/' {
+					// "**/" matches zero or more directories
+					i++
+					expr.WriteString("(.*/)?")
+				} else {
+					expr.WriteString(".*")
+				}
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		case '[':
+			end, err := writeClass(&expr, runes, i)
+			if err != nil {
+				return nil, fmt.Errorf("invalid glob pattern %q: %w", source, err)
+			}
+			i = end
+		case '\\':
+			if i+1 == len(runes) {
+				return nil, fmt.Errorf("invalid glob pattern %q: trailing backslash", source)
+			}
+			i++
+			expr.WriteString(regexp.QuoteMeta(string(runes[i])))
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+	}
+
+	return &Pattern{source: source, re: re}, nil
+}
+
+// writeClass writes the regular expression for the character class starting
+// at runes[start] and returns the index of its closing ']'
+func writeClass(expr *strings.Builder, runes []rune, start int) (int, error) {
+	i := start + 1
+	negate := i < len(runes) && (runes[i] == '!' || runes[i] == '^')
+	if negate {
+		i++
+	}
+
+	var class strings.Builder
+	for first := true; ; first = false {
+		if i == len(runes) {
+			return 0, fmt.Errorf("unterminated character class")
+		}
+		r := runes[i]
+		if r == ']' && !first {
+			break
+		}
+		escaped := r == '\\'
+		if escaped {
+			if i+1 == len(runes) {
+				return 0, fmt.Errorf("unterminated character class")
+			}
+			i++
+			r = runes[i]
+		}
+		switch r {
+		case '\\', ']', '[', '^':
+			class.WriteRune('\\')
+		case '-':
+			// A range unless escaped or at either end of the class
+			if escaped || first || (i+1 < len(runes) && runes[i+1] == ']') {
+				class.WriteRune('\\')
+			}
+		}
+		class.WriteRune(r)
+		i++
+	}
+
+	if negate {
+		// Like '?', a negated class never matches across directories
+		fmt.Fprintf(expr, "[^/%s]", class.String())
+	} else {
+		fmt.Fprintf(expr, "[%s]", class.String())
+	}
+	return i, nil
+}
+
+// Match reports whether a path matches the pattern. A leading slash on the
+// path is ignored.
+func (p *Pattern) Match(path string) bool {
+	return p.re.MatchString(strings.TrimPrefix(path, "/"))
+}
+
+// String returns the pattern as it was written
+func (p *Pattern) String() string {
+	return p.source
+}
+
+// IsLiteral reports whether a pattern has no wildcards or escapes and so only
+// matches itself
+func IsLiteral(pattern string) bool {
+	return !strings.ContainsAny(pattern, "*?[\\")
+}
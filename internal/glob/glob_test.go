@@ -0,0 +1,128 @@
+package glob
+
+import "testing"
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.cs", "Foo.cs", true},
+		{"*.cs", "src/app/Foo.cs", true},
+		{"*.cs", "Foo.csproj", false},
+		{"src/*.cs", "src/Foo.cs", true},
+		{"src/*.cs", "src/app/Foo.cs", false},
+		{"src/**/*.cs", "src/Foo.cs", true},
+		{"src/**/*.cs", "src/app/deep/Foo.cs", true},
+		{"src/**", "src/app/Foo.cs", true},
+		{"/src/*.cs", "/src/Foo.cs", true},
+		{"?.txt", "a.txt", true},
+		{"?.txt", "ab.txt", false},
+		{"a?b", "a/b", false},
+		{"file[0-9].txt", "file7.txt", true},
+		{"file[0-9].txt", "filex.txt", false},
+		{"file[abc].txt", "fileb.txt", true},
+		{"file[!abc].txt", "filed.txt", true},
+		{"file[!abc].txt", "filea.txt", false},
+		{"a[!x]b", "a/b", false},
+		{"[]]", "]", true},
+		{"[a-]", "-", true},
+		{"[\\]]", "]", true},
+		{"\\*.txt", "*.txt", true},
+		{"\\*.txt", "a.txt", false},
+		{"a\\?", "a?", true},
+		{"a\\?", "ab", false},
+		{"a.b", "axb", false},
+		{"(x)+", "(x)+", true},
+		{"Ünïcode*", "Ünïcode.md", true},
+		{"Repo", "repo", false},
+	}
+
+	for _, tt := range tests {
+		pattern, err := Compile(tt.pattern)
+		if err != nil {
+			t.Errorf("Compile(%q) error = %v", tt.pattern, err)
+			continue
+		}
+		if got := pattern.Match(tt.path); got != tt.want {
+			t.Errorf("Compile(%q).Match(%q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCompileInvalid(t *testing.T) {
+	for _, pattern := range []string{"", "  ", "[abc", "a[", "[]", "abc\\", "[a\\", "[z-a]"} {
+		if _, err := Compile(pattern); err == nil {
+			t.Errorf("Compile(%q) succeeded, want an error", pattern)
+		}
+	}
+}
+
+func TestCompileName(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"main", "main", true},
+		{"main", "feature/main", false},
+		{"release/*", "release/1.0", true},
+		{"release/*", "release/1.0/hotfix", false},
+		{"release/**", "release/1.0/hotfix", true},
+	}
+
+	for _, tt := range tests {
+		pattern, err := CompileName(tt.pattern)
+		if err != nil {
+			t.Errorf("CompileName(%q) error = %v", tt.pattern, err)
+			continue
+		}
+		if got := pattern.Match(tt.name); got != tt.want {
+			t.Errorf("CompileName(%q).Match(%q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompileIgnoreCase(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"Repo", "repo", true},
+		{"web-*", "Web-Frontend", true},
+		{"[a-c]*", "Backend", true},
+		{"[!a-c]*", "Backend", false},
+		{"web-*", "api", false},
+	}
+
+	for _, tt := range tests {
+		pattern, err := CompileIgnoreCase(tt.pattern)
+		if err != nil {
+			t.Errorf("CompileIgnoreCase(%q) error = %v", tt.pattern, err)
+			continue
+		}
+		if got := pattern.Match(tt.name); got != tt.want {
+			t.Errorf("CompileIgnoreCase(%q).Match(%q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsLiteral(t *testing.T) {
+	tests := map[string]bool{
+		"Project":   true,
+		"My.Repo":   true,
+		"web-*":     false,
+		"repo?":     false,
+		"repo[12]":  false,
+		"repo\\*":   false,
+		"feature/x": true,
+	}
+
+	for pattern, want := range tests {
+		if got := IsLiteral(pattern); got != want {
+			t.Errorf("IsLiteral(%q) = %v, want %v", pattern, got, want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/azuredevops"
+)
+
+// timelineItem is a stage, job or task in the build details list
+type timelineItem struct {
+	record azuredevops.TimelineRecord
+	depth  int
+}
+
+func (i timelineItem) FilterValue() string {
+	return i.record.Name
+}
+
+func (i timelineItem) Title() string {
+	status := i.record.State
+	if i.record.Result != "" {
+		status = i.record.Result
+	}
+
+	title := fmt.Sprintf("%s%s %s", strings.Repeat("  ", i.depth), getStatusIcon(status), i.record.Name)
+	if i.record.Type == azuredevops.RecordTypeStage {
+		title = lipgloss.NewStyle().Bold(true).Render(title)
+	}
+
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	if duration := i.record.Duration(); duration > 0 {
+		title += dimStyle.Render(" " + formatDuration(duration))
+	}
+	if i.record.ErrorCount > 0 {
+		title += errorStyle.Render(fmt.Sprintf(" ✗%d", i.record.ErrorCount))
+	}
+	if i.record.WarningCount > 0 {
+		title += lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render(fmt.Sprintf(" ⚠%d", i.record.WarningCount))
+	}
+	return title
+}
+
+func (i timelineItem) Description() string {
+	return ""
+}
+
+// formatDuration formats a duration as e.g. 45s, 3m 12s or 1h 5m
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm %ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// timelineItems flattens a timeline tree into list items
+func timelineItems(nodes []*azuredevops.TimelineNode, depth int) []list.Item {
+	var items []list.Item
+	for _, node := range nodes {
+		items = append(items, timelineItem{record: node.Record, depth: depth})
+		items = append(items, timelineItems(node.Children, depth+1)...)
+	}
+	return items
+}
+
+// buildProject returns the project a build belongs to. Builds name their
+// project, but older servers may leave it out, so the project of the pipeline
+// source the build was loaded from is used as a fallback.
+func (m Model) buildProject(item buildItem) string {
+	if item.build.Project.Name != "" {
+		return item.build.Project.Name
+	}
+	return m.config.Pipelines[item.source].Project
+}
+
+// openBuild shows the build details view of a build and loads its timeline
+func (m *Model) openBuild(item buildItem) tea.Cmd {
+	build := item.build
+	m.selectedBuild = &build
+	m.selectedBuildProject = m.buildProject(item)
+	m.timelineList.SetItems(nil)
+	m.loadingTimeline = true
+	m.view = ViewBuildDetails
+	ctx, seq := m.beginViewRequest()
+	return m.loadBuildTimeline(ctx, seq, m.selectedBuildProject, build.ID)
+}
+
+// loadBuildTimeline loads the timeline of a build
+func (m Model) loadBuildTimeline(ctx context.Context, seq int, project string, buildID int) tea.Cmd {
+	return func() tea.Msg {
+		records, err := m.client.GetBuildTimeline(ctx, project, buildID)
+		return TimelineLoadedMsg{seq: seq, records: records, err: err}
+	}
+}
+
+// showTimeline shows a loaded build timeline
+func (m *Model) showTimeline(msg TimelineLoadedMsg) {
+	m.loadingTimeline = false
+	if msg.err != nil {
+		m.err = msg.err
+		return
+	}
+	m.timelineList.SetItems(timelineItems(azuredevops.TimelineTree(msg.records), 0))
+}
+
+// openRecordLog shows the log of the selected stage, job or task
+func (m *Model) openRecordLog() tea.Cmd {
+	item, ok := m.timelineList.SelectedItem().(timelineItem)
+	if !ok {
+		return nil
+	}
+	if item.record.Log == nil {
+		m.notice = fmt.Sprintf("%s has no log", item.record.Name)
+		return nil
+	}
+	return m.openBuildLogs(item.record.Log.ID, ViewBuildDetails)
+}
+
+// renderBuildDetails renders the stages, jobs and tasks of the selected build
+func (m Model) renderBuildDetails() string {
+	var s strings.Builder
+	build := m.selectedBuild
+
+	status := build.Status
+	if build.Result != "" {
+		status = build.Result
+	}
+	s.WriteString(titleStyle.Render(fmt.Sprintf("Build #%s: %s", build.BuildNumber, build.Definition.Name)))
+	s.WriteString("\n")
+	s.WriteString(fmt.Sprintf("Status: %s | Branch: %s", getColoredStatus(status), strings.TrimPrefix(build.SourceBranch, "refs/heads/")))
+	if !build.StartTime.IsZero() {
+		end := build.FinishTime
+		if end.IsZero() {
+			end = time.Now()
+		}
+		s.WriteString(" | Duration: " + formatDuration(end.Sub(build.StartTime)))
+	}
+	s.WriteString("\n\n")
+
+	if m.loadingTimeline {
+		s.WriteString("\n  Loading timeline...\n")
+	} else {
+		s.WriteString(m.timelineList.View())
+	}
+	s.WriteString("\n")
+
+	// Issues of the selected record
+	if item, ok := m.timelineList.SelectedItem().(timelineItem); ok {
+		for i, issue := range item.record.Issues {
+			if i == 3 {
+				s.WriteString(statusStyle.Render(fmt.Sprintf("... %d more", len(item.record.Issues)-i)))
+				s.WriteString("\n")
+				break
+			}
+			message, _, _ := strings.Cut(issue.Message, "\n")
+			line := lipgloss.NewStyle().MaxWidth(m.width - 4).Render(fmt.Sprintf("%s: %s", issue.Type, message))
+			if issue.Type == "error" {
+				line = errorStyle.Render(line)
+			}
+			s.WriteString(line)
+			s.WriteString("\n")
+		}
+	}
+
+	statusText := "Press 'enter' to view the selected log, 'l' to browse all logs, 'g' to open in browser, 'h' or left arrow to go back, 'q' to quit"
+	if m.notice != "" {
+		statusText = m.notice + " | " + statusText
+	}
+	s.WriteString(statusStyle.Render(statusText))
+
+	if m.err != nil {
+		s.WriteString("\n")
+		s.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+	}
+
+	return s.String()
+}
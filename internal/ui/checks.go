@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/azuredevops"
+)
+
+// getCheckIcon returns a colored icon for the overall state of a PR's checks
+func getCheckIcon(state string) string {
+	switch state {
+	case azuredevops.CheckPassed:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render("✓") // Green
+	case azuredevops.CheckFailed:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render("✗") // Red
+	case azuredevops.CheckPending:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render("●") // Yellow
+	}
+	return ""
+}
+
+// getPRStatusIcon returns a colored icon for a status posted to a PR
+func getPRStatusIcon(status azuredevops.PRStatus) string {
+	switch status.State {
+	case azuredevops.StatusStateSucceeded:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render("✓") // Green
+	case azuredevops.StatusStateFailed, azuredevops.StatusStateError:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render("✗") // Red
+	case azuredevops.StatusStatePending, azuredevops.StatusStateNotSet:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render("●") // Yellow
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("○") // Gray
+}
+
+// formatChecks renders the policy evaluations and statuses of a PR as a checklist
+func formatChecks(pr *azuredevops.PullRequest) string {
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	checks := pr.Checks
+	if pr.ChecksErr != nil {
+		return errorStyle.Render(fmt.Sprintf("Failed to load checks: %v", pr.ChecksErr)) + "\n"
+	}
+	if checks == nil {
+		return dimStyle.Italic(true).Render("Loading checks...") + "\n"
+	}
+	if checks.State() == "" {
+		return dimStyle.Italic(true).Render("(No policies or status checks)") + "\n"
+	}
+
+	var s strings.Builder
+	for _, policy := range checks.Policies {
+		if !policy.Configuration.IsEnabled {
+			continue
+		}
+		line := fmt.Sprintf("%s %s (%s)", getPolicyStatusIcon(policy), policy.Name(), policy.Status)
+		if policy.Blocks() {
+			line += errorStyle.Render(" blocking")
+		} else if !policy.Configuration.IsBlocking {
+			line += dimStyle.Render(" optional")
+		}
+		if policy.Context.BuildID != 0 {
+			line += dimStyle.Render(fmt.Sprintf(" build %d", policy.Context.BuildID))
+		}
+		s.WriteString(line + "\n")
+	}
+	for _, status := range checks.Statuses {
+		line := fmt.Sprintf("%s %s (%s)", getPRStatusIcon(status), status.Name(), status.State)
+		if status.Description != "" {
+			line += dimStyle.Render(" " + status.Description)
+		}
+		s.WriteString(line + "\n")
+	}
+	return s.String()
+}
+
+// validatingBuild returns the build policy evaluation whose build is most
+// worth looking at: a failing one before a running one before any other
+func validatingBuild(checks *azuredevops.PRChecks) (azuredevops.PolicyEvaluation, bool) {
+	if checks == nil {
+		return azuredevops.PolicyEvaluation{}, false
+	}
+
+	var found azuredevops.PolicyEvaluation
+	rank := func(policy azuredevops.PolicyEvaluation) int {
+		switch policy.Status {
+		case azuredevops.PolicyStatusRejected, azuredevops.PolicyStatusBroken:
+			return 3
+		case azuredevops.PolicyStatusRunning, azuredevops.PolicyStatusQueued:
+			return 2
+		}
+		return 1
+	}
+	for _, policy := range checks.Policies {
+		if policy.Context.BuildID == 0 {
+			continue
+		}
+		if found.Context.BuildID == 0 || rank(policy) > rank(found) {
+			found = policy
+		}
+	}
+	return found, found.Context.BuildID != 0
+}
+
+// openValidatingBuild shows the logs of the build validating the selected PR
+func (m *Model) openValidatingBuild() tea.Cmd {
+	policy, ok := validatingBuild(m.selectedPR.Checks)
+	if !ok {
+		m.notice = fmt.Sprintf("PR #%d has no validation build", m.selectedPR.ID)
+		return nil
+	}
+
+	m.selectedBuild = &azuredevops.Build{
+		ID:          policy.Context.BuildID,
+		BuildNumber: strconv.Itoa(policy.Context.BuildID),
+		Definition:  azuredevops.Definition{ID: policy.Configuration.Settings.BuildDefinitionID},
+		Project:     m.selectedPR.Repository.Project,
+	}
+	m.selectedBuildProject = m.selectedPR.Repository.Project.Name
+	return m.openBuildLogs(0, ViewPRDetails)
+}
@@ -1,109 +1,654 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/ulve/azuredevops-terminal-dashboard/internal/azuredevops"
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/config"
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/glob"
 )
 
-// loadData loads pull requests and builds from Azure DevOps
-func (m Model) loadData() tea.Cmd {
+// quit cancels every request in flight, including writes that are still being
+// retried, and quits the program
+func (m Model) quit() tea.Cmd {
+	m.cancel()
+	return tea.Quit
+}
+
+// beginRefresh cancels any refresh still in flight and returns the context and
+// sequence number for a new one
+func (m *Model) beginRefresh() (context.Context, int) {
+	if m.refreshCancel != nil {
+		m.refreshCancel()
+	}
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.refreshCtx = ctx
+	m.refreshCancel = cancel
+	m.refreshSeq++
+	m.prStateSlots = make(chan struct{}, m.config.Concurrency)
+
+	return ctx, m.refreshSeq
+}
+
+// beginViewRequest cancels any view request still in flight and returns the
+// context and sequence number for a new one
+func (m *Model) beginViewRequest() (context.Context, int) {
+	m.cancelViewRequest()
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.viewCancel = cancel
+
+	return ctx, m.viewSeq
+}
+
+// cancelViewRequest cancels the view request in flight, if any, so that its
+// result is discarded when it arrives
+func (m *Model) cancelViewRequest() {
+	if m.viewCancel != nil {
+		m.viewCancel()
+		m.viewCancel = nil
+	}
+	m.viewSeq++
+}
+
+// cancelPrefetch cancels the diff prefetches in flight so that their results
+// are discarded when they arrive
+func (m *Model) cancelPrefetch() {
+	if m.prefetchCancel != nil {
+		m.prefetchCancel()
+		m.prefetchCancel = nil
+	}
+	m.prefetchSeq++
+	m.diffClaims = newDiffClaims()
+}
+
+// prefetchContext returns the context of the current prefetch generation,
+// starting one if none is running
+func (m *Model) prefetchContext() context.Context {
+	if m.prefetchCancel == nil {
+		ctx, cancel := context.WithCancel(m.ctx)
+		m.prefetchCtx = ctx
+		m.prefetchCancel = cancel
+	}
+	return m.prefetchCtx
+}
+
+// diffClaims records the file diffs being prefetched in the background, so a
+// file isn't fetched twice
+type diffClaims struct {
+	mu    sync.Mutex
+	paths map[string]bool
+}
+
+// newDiffClaims creates an empty set of claims
+func newDiffClaims() *diffClaims {
+	return &diffClaims{paths: make(map[string]bool)}
+}
+
+// claim marks a path as being fetched, returning false if it already was
+func (c *diffClaims) claim(path string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.paths[path] {
+		return false
+	}
+	c.paths[path] = true
+	return true
+}
+
+// release allows a path whose fetch failed to be fetched again
+func (c *diffClaims) release(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.paths, path)
+}
+
+// sourceKind identifies the type of a configured data source
+type sourceKind int
+
+const (
+	sourcePullRequests sourceKind = iota
+	sourcePipeline
+)
+
+// sourceID identifies a configured source by its kind and index in the config
+type sourceID struct {
+	kind  sourceKind
+	index int
+}
+
+// sourceIDs returns every configured source in display order
+func (m Model) sourceIDs() []sourceID {
+	ids := make([]sourceID, 0, len(m.config.PullRequests)+len(m.config.Pipelines))
+	for i := range m.config.PullRequests {
+		ids = append(ids, sourceID{kind: sourcePullRequests, index: i})
+	}
+	for i := range m.config.Pipelines {
+		ids = append(ids, sourceID{kind: sourcePipeline, index: i})
+	}
+	return ids
+}
+
+// sourceName returns a human readable name for a configured source
+func (m Model) sourceName(id sourceID) string {
+	if id.kind == sourcePullRequests {
+		prConfig := m.config.PullRequests[id.index]
+		repository := prConfig.Repository
+		if repository == "" {
+			repository = "*"
+		}
+		return fmt.Sprintf("%s/%s", prConfig.Project, repository)
+	}
+
+	pipelineConfig := m.config.Pipelines[id.index]
+	pipelineIdentifier := pipelineConfig.Pipeline
+	if pipelineConfig.DefinitionID > 0 {
+		pipelineIdentifier = fmt.Sprintf("ID:%d", pipelineConfig.DefinitionID)
+	}
+	return fmt.Sprintf("%s/%s", pipelineConfig.Project, pipelineIdentifier)
+}
+
+// loadSource loads the pull requests or builds of a single configured source
+func (m Model) loadSource(ctx context.Context, id sourceID) SourceLoadedMsg {
+	msg := SourceLoadedMsg{source: id}
+
+	if id.kind == sourcePullRequests {
+		prConfig := m.config.PullRequests[id.index]
+		prs, err := m.fetchPullRequests(ctx, prConfig)
+		var partial *projectsError
+		switch {
+		case errors.As(err, &partial):
+			msg.partialErr = fmt.Errorf("failed to load some PRs for %s: %w", m.sourceName(id), err)
+		case err != nil:
+			msg.err = fmt.Errorf("failed to load PRs for %s: %w", m.sourceName(id), err)
+		}
+		msg.pullRequests = prs
+		return msg
+	}
+
+	pipelineConfig := m.config.Pipelines[id.index]
+	builds, err := m.client.GetBuilds(ctx, pipelineConfig.Project, pipelineConfig.Pipeline, pipelineConfig.DefinitionID, pipelineConfig.Limit)
+	if err != nil {
+		msg.err = fmt.Errorf("failed to load builds for %s: %w", m.sourceName(id), err)
+	}
+	msg.builds = builds
+	return msg
+}
+
+// fetchPullRequests fetches the active pull requests of a pull request source.
+// A single repository is queried directly. Otherwise every matching project
+// is queried as a whole and the PRs are filtered by the repository glob. The
+// source's other filters are applied on top.
+func (m Model) fetchPullRequests(ctx context.Context, prConfig config.PullRequestConfig) ([]azuredevops.PullRequest, error) {
+	filter, err := m.newSourceFilter(ctx, prConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	direct := glob.IsLiteral(prConfig.Project) && prConfig.Repository != "" && glob.IsLiteral(prConfig.Repository)
+	if direct {
+		filter.repository = nil // Already scoped by the server
+	}
+
+	// The limit can only be left to the server when no PRs are filtered out here
+	limit := prConfig.Limit
+	if filter.clientSide() {
+		limit = 0
+	}
+
+	if direct {
+		prs, err := m.client.GetPullRequests(ctx, prConfig.Project, prConfig.Repository, filter.criteria, limit)
+		if err != nil {
+			return nil, err
+		}
+		return filterPullRequests(prs, filter, prConfig.Limit), nil
+	}
+
+	projects := []string{prConfig.Project}
+	if !glob.IsLiteral(prConfig.Project) {
+		all, err := m.client.GetProjects(ctx)
+		if err != nil {
+			return nil, err
+		}
+		projectPattern, _ := glob.CompileIgnoreCase(prConfig.Project) // Validated when the config was loaded
+		projects = nil
+		for _, project := range all {
+			if projectPattern.Match(project.Name) {
+				projects = append(projects, project.Name)
+			}
+		}
+	}
+
+	// Query the projects with a bounded pool of workers, keeping their order
+	projectPRs := make([][]azuredevops.PullRequest, len(projects))
+	projectErrs := make([]error, len(projects))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(m.config.Concurrency, len(projects)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				prs, err := m.client.GetProjectPullRequests(ctx, projects[i], filter.criteria, limit)
+				if err != nil {
+					projectErrs[i] = fmt.Errorf("%s: %w", projects[i], err)
+					continue
+				}
+				projectPRs[i] = filterPullRequests(prs, filter, 0)
+			}
+		}()
+	}
+	for i := range projects {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Projects that fail, e.g. without Repos or permission to it, don't hide
+	// the PRs of the others
+	var prs []azuredevops.PullRequest
+	var failed projectsError
+	for i := range projects {
+		if projectErrs[i] != nil {
+			failed.errs = append(failed.errs, projectErrs[i])
+			continue
+		}
+		prs = append(prs, projectPRs[i]...)
+	}
+	if prConfig.Limit > 0 && len(prs) > prConfig.Limit {
+		prs = prs[:prConfig.Limit]
+	}
+
+	switch {
+	case len(failed.errs) == 0:
+		return prs, nil
+	case len(failed.errs) == len(projects):
+		return nil, errors.New(failed.Error())
+	}
+	return prs, &failed
+}
+
+// projectsError reports the projects of a pull request source whose PRs could
+// not be loaded while those of the other projects were
+type projectsError struct {
+	errs []error
+}
+
+func (e *projectsError) Error() string {
+	msgs := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d project(s) failed: %s", len(e.errs), strings.Join(msgs, "; "))
+}
+
+func (e *projectsError) Unwrap() []error {
+	return e.errs
+}
+
+// filterPullRequests returns the PRs that pass a source filter, at most limit
+// of them unless limit is 0
+func filterPullRequests(prs []azuredevops.PullRequest, filter sourceFilter, limit int) []azuredevops.PullRequest {
+	var kept []azuredevops.PullRequest
+	for _, pr := range prs {
+		if !filter.matches(pr) {
+			continue
+		}
+		kept = append(kept, pr)
+		if limit > 0 && len(kept) == limit {
+			break
+		}
+	}
+	return kept
+}
+
+// maxPRStates is the most PRs of a source whose checks and vote state are
+// loaded on each refresh, so large sources don't flood the API
+const maxPRStates = 100
+
+// prState holds the details of a PR that are loaded after its source's list
+type prState struct {
+	repositoryName string
+	prID           int
+	checks         *azuredevops.PRChecks
+	checksErr      error
+	voteReset      bool
+}
+
+// loadPRStates returns a command that loads the policy evaluations and
+// statuses of PRs that are already shown, and whether the authenticated
+// user's vote on them was reset. Only the first maxPRStates PRs are loaded.
+// The requests of every source share the refresh's bounded worker slots and
+// are canceled with it.
+func (m Model) loadPRStates(seq int, prs []azuredevops.PullRequest) tea.Cmd {
+	if len(prs) == 0 {
+		return nil
+	}
+	if len(prs) > maxPRStates {
+		prs = prs[:maxPRStates]
+	}
+	ctx, slots, client := m.refreshCtx, m.prStateSlots, m.client
+
 	return func() tea.Msg {
-		var allPRs []azuredevops.PullRequest
-		var allBuilds []azuredevops.Build
-		var lastErr error
-
-		// Load pull requests
-		for _, prConfig := range m.config.PullRequests {
-			prs, err := m.client.GetPullRequests(prConfig.Project, prConfig.Repository)
-			if err != nil {
-				lastErr = fmt.Errorf("failed to load PRs for %s/%s: %w", prConfig.Project, prConfig.Repository, err)
-				continue
+		identity, identityErr := client.CurrentUser(ctx)
+
+		states := make([]prState, len(prs))
+		var wg sync.WaitGroup
+
+	queue:
+		for i := range prs {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				break queue
 			}
-			allPRs = append(allPRs, prs...)
+
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-slots }()
+
+				pr := prs[i]
+				state := prState{repositoryName: pr.Repository.Name, prID: pr.ID}
+				checks, err := client.GetPRChecks(ctx, pr.Repository.Project.Name, pr.Repository.Project.ID, pr.Repository.Name, pr.ID)
+				if err != nil {
+					state.checksErr = err
+				} else {
+					state.checks = &checks
+				}
+
+				// Only PRs the user has been asked to review but hasn't voted on
+				// can have a reset vote
+				if reviewer, ok := pr.Reviewer(identity.ID); identityErr == nil && ok && reviewer.Vote == azuredevops.VoteNone {
+					threads, err := client.GetPRThreads(ctx, pr.Repository.Project.Name, pr.Repository.Name, pr.ID, 0)
+					if err == nil {
+						lastVote, voted := azuredevops.LastVote(threads, identity.ID)
+						state.voteReset = voted && lastVote != azuredevops.VoteNone
+					}
+				}
+				states[i] = state
+			}(i)
+		}
+		wg.Wait()
+
+		if ctx.Err() != nil {
+			return nil // Superseded by a newer refresh
 		}
+		return PRStatesLoadedMsg{seq: seq, states: states}
+	}
+}
+
+// loadData loads pull requests and builds from Azure DevOps. Sources are loaded
+// by a bounded pool of workers and each result is delivered as its own
+// SourceLoadedMsg, followed by a RefreshDoneMsg once every source has finished.
+func (m Model) loadData(ctx context.Context, seq int) tea.Cmd {
+	ids := m.sourceIDs()
+	results := make(chan SourceLoadedMsg)
 
-		// Load builds
-		for _, pipelineConfig := range m.config.Pipelines {
-			builds, err := m.client.GetBuilds(pipelineConfig.Project, pipelineConfig.Pipeline, pipelineConfig.DefinitionID)
-			if err != nil {
-				pipelineIdentifier := pipelineConfig.Pipeline
-				if pipelineConfig.DefinitionID > 0 {
-					pipelineIdentifier = fmt.Sprintf("ID:%d", pipelineConfig.DefinitionID)
+	workers := m.config.Concurrency
+	if workers > len(ids) {
+		workers = len(ids)
+	}
+
+	go func() {
+		jobs := make(chan sourceID)
+		var wg sync.WaitGroup
+
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for id := range jobs {
+					msg := m.loadSource(ctx, id)
+					msg.seq = seq
+					select {
+					case results <- msg:
+					case <-ctx.Done():
+					}
 				}
-				lastErr = fmt.Errorf("failed to load builds for %s/%s: %w", pipelineConfig.Project, pipelineIdentifier, err)
-				continue
+			}()
+		}
+
+	queue:
+		for _, id := range ids {
+			select {
+			case jobs <- id:
+			case <-ctx.Done():
+				break queue
 			}
-			allBuilds = append(allBuilds, builds...)
 		}
+		close(jobs)
 
-		return DataLoadedMsg{
-			pullRequests: allPRs,
-			builds:       allBuilds,
-			err:          lastErr,
+		wg.Wait()
+		close(results)
+	}()
+
+	return waitForSource(seq, results)
+}
+
+// waitForSource returns a command that delivers the next result of a running
+// refresh, or a RefreshDoneMsg once all sources have been loaded
+func waitForSource(seq int, results <-chan SourceLoadedMsg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-results
+		if !ok {
+			return RefreshDoneMsg{seq: seq}
 		}
+		msg.results = results
+		return msg
 	}
 }
 
 // loadPRFiles loads the files changed in a pull request
-func (m Model) loadPRFiles(pr *azuredevops.PullRequest) tea.Cmd {
+func (m Model) loadPRFiles(ctx context.Context, seq int, pr *azuredevops.PullRequest) tea.Cmd {
 	return func() tea.Msg {
-		files, err := m.client.GetPRFiles(pr.Repository.Project.Name, pr.Repository.Name, pr.ID)
+		iteration, err := m.client.GetLatestIteration(ctx, pr.Repository.Project.Name, pr.Repository.Name, pr.ID)
+		if err != nil {
+			return FilesLoadedMsg{seq: seq, err: fmt.Errorf("failed to load PR files: %w", err)}
+		}
+
+		files, err := m.client.GetPRFiles(ctx, pr.Repository.Project.Name, pr.Repository.Name, pr.ID, iteration)
+		if err != nil {
+			return FilesLoadedMsg{seq: seq, err: fmt.Errorf("failed to load PR files: %w", err)}
+		}
+
+		// Resolve the commits once so each file diff only fetches its two versions
+		commits, err := m.client.GetPRCommits(ctx, pr.Repository.Project.Name, pr.Repository.Name, pr.ID)
 		if err != nil {
-			return FilesLoadedMsg{err: fmt.Errorf("failed to load PR files: %w", err)}
+			return FilesLoadedMsg{seq: seq, err: fmt.Errorf("failed to load PR commits: %w", err)}
 		}
 
-		return FilesLoadedMsg{files: files}
+		return FilesLoadedMsg{seq: seq, files: files, commits: commits, iteration: iteration}
 	}
 }
 
+// fetchFileDiff loads and highlights the diff of the file at index in prFiles
+func (m Model) fetchFileDiff(ctx context.Context, index int) DiffLoadedMsg {
+	pr := m.selectedPR
+	change := m.prFiles[index]
+
+	diff, err := m.client.GetFileDiff(ctx, pr.Repository.Project.Name, pr.Repository.Name, m.prCommits, change, m.config.DiffContextLines)
+	if err != nil {
+		return DiffLoadedMsg{index: index, err: fmt.Errorf("failed to load file diff: %w", err)}
+	}
+
+	// Highlight both versions here so large files don't block the UI
+	highlight := fileHighlight{}
+	if !diff.IsBinary {
+		highlight.oldLines = highlightLines(diff.OldPath, diff.OldText)
+		highlight.newLines = highlightLines(diff.NewPath, diff.NewText)
+	}
+
+	return DiffLoadedMsg{index: index, diff: diff, highlight: highlight}
+}
+
 // loadFileDiff loads the diff for a file in a pull request
-func (m Model) loadFileDiff(pr *azuredevops.PullRequest, filePath string) tea.Cmd {
+func (m Model) loadFileDiff(ctx context.Context, seq int, index int) tea.Cmd {
 	return func() tea.Msg {
-		diff, err := m.client.GetPRFileDiff(pr.Repository.Project.Name, pr.Repository.Name, pr.ID, filePath)
-		if err != nil {
-			return DiffLoadedMsg{err: fmt.Errorf("failed to load file diff: %w", err)}
+		msg := m.fetchFileDiff(ctx, index)
+		msg.seq = seq
+		return msg
+	}
+}
+
+// openFileDiff shows the diff of the file at index in prFiles, straight from
+// the cache when it has already been loaded
+func (m *Model) openFileDiff(index int) tea.Cmd {
+	m.fileList.Select(index)
+
+	if cached, ok := m.diffCache[m.prFiles[index].Item.Path]; ok {
+		m.cancelViewRequest()
+		m.showFileDiff(cached)
+		return m.prefetchNeighbours(index)
+	}
+
+	ctx, seq := m.beginViewRequest()
+	return m.loadFileDiff(ctx, seq, index)
+}
+
+// showFileDiff makes a loaded diff the current one and switches to the diff view
+func (m *Model) showFileDiff(msg DiffLoadedMsg) {
+	m.err = nil // Clear any previous errors
+	m.notice = ""
+	m.diffIndex = msg.index
+	m.currentDiff = msg.diff
+	m.diffHighlight = msg.highlight
+	m.diffCursor = 0
+	m.refreshDiffView()
+	m.diffViewport.GotoTop()
+	m.view = ViewFileDiff
+}
+
+// prefetchNeighbours loads the diffs of the files next to index in the
+// background so moving between files is instant
+func (m *Model) prefetchNeighbours(index int) tea.Cmd {
+	ctx, seq := m.prefetchContext(), m.prefetchSeq
+
+	var cmds []tea.Cmd
+	for _, neighbour := range []int{m.neighbourFile(index, 1), m.neighbourFile(index, -1)} {
+		if neighbour < 0 {
+			continue
+		}
+		path := m.prFiles[neighbour].Item.Path
+		if _, ok := m.diffCache[path]; ok || !m.diffClaims.claim(path) {
+			continue
 		}
 
-		return DiffLoadedMsg{diff: diff}
+		model, neighbour := *m, neighbour
+		cmds = append(cmds, func() tea.Msg {
+			return DiffPrefetchedMsg{seq: seq, path: path, diff: model.fetchFileDiff(ctx, neighbour)}
+		})
 	}
+
+	return tea.Batch(cmds...)
 }
 
-// loadBuildLogs loads the logs for a build
-func (m Model) loadBuildLogs(build *azuredevops.Build) tea.Cmd {
-	return func() tea.Msg {
-		// Get the project name from the build - we'll need to find it from config
-		// For now, we'll try all projects in the config
-		var logs string
-		var lastErr error
-
-		for _, pipelineConfig := range m.config.Pipelines {
-			buildLogs, err := m.client.GetBuildLogs(pipelineConfig.Project, build.ID)
-			if err != nil {
-				lastErr = err
-				continue
-			}
+// loadDiffStats counts the added and deleted lines of the files not hidden by
+// configuration in the background, using a bounded pool of workers, so the
+// file tree can show line counts. Only the first maxDiffStatsFiles files are
+// counted, and the diffs are neither highlighted nor kept. The counts are
+// delivered in batches of DiffStatsLoadedMsg.
+func (m *Model) loadDiffStats() tea.Cmd {
+	ctx, seq := m.prefetchContext(), m.prefetchSeq
+	results := make(chan fileLineStats)
 
-			// Concatenate all log files
-			for _, log := range buildLogs {
-				content, err := m.client.GetBuildLogContent(pipelineConfig.Project, build.ID, log.ID)
-				if err != nil {
-					continue
+	var indices []int
+	for i := range m.prFiles {
+		if len(indices) == maxDiffStatsFiles {
+			break
+		}
+		if m.fileVisible(i) {
+			indices = append(indices, i)
+			m.diffStats[m.prFiles[i].Item.Path] = lineStats{pending: 1}
+		}
+	}
+
+	workers := m.config.Concurrency
+	if workers > len(indices) {
+		workers = len(indices)
+	}
+
+	client, pr, files := m.client, m.selectedPR, m.prFiles
+	commits, contextLines := m.prCommits, m.config.DiffContextLines
+	go func() {
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for index := range jobs {
+					change := files[index]
+					result := fileLineStats{path: change.Item.Path}
+					// A file that fails here just shows no counts
+					diff, err := client.GetFileDiff(ctx, pr.Repository.Project.Name, pr.Repository.Name, commits, change, contextLines)
+					if err == nil {
+						result.stats = diffLineStats(diff)
+					}
+					select {
+					case results <- result:
+					case <-ctx.Done():
+					}
 				}
-				logs += fmt.Sprintf("=== Log %d ===\n%s\n\n", log.ID, content)
-			}
+			}()
+		}
 
-			// If we got logs, return them
-			if logs != "" {
-				return LogsLoadedMsg{logs: logs}
+	queue:
+		for _, index := range indices {
+			select {
+			case jobs <- index:
+			case <-ctx.Done():
+				break queue
 			}
 		}
+		close(jobs)
+
+		wg.Wait()
+		close(results)
+	}()
+
+	return waitForDiffStats(seq, results)
+}
+
+// waitForDiffStats returns a command that delivers the line counts counted
+// during the next diffStatsBatchInterval as one DiffStatsLoadedMsg, so the
+// file tree is rebuilt once per batch rather than once per file. It delivers
+// nothing once the counting has finished.
+func waitForDiffStats(seq int, results <-chan fileLineStats) tea.Cmd {
+	return func() tea.Msg {
+		first, ok := <-results
+		if !ok {
+			return nil
+		}
 
-		if lastErr != nil {
-			return LogsLoadedMsg{err: fmt.Errorf("failed to load build logs: %w", lastErr)}
+		msg := DiffStatsLoadedMsg{
+			seq:     seq,
+			stats:   map[string]lineStats{first.path: first.stats},
+			results: results,
 		}
 
-		return LogsLoadedMsg{logs: "No logs available for this build"}
+		timer := time.NewTimer(diffStatsBatchInterval)
+		defer timer.Stop()
+		for {
+			select {
+			case result, ok := <-results:
+				if !ok {
+					return msg
+				}
+				msg.stats[result.path] = result.stats
+			case <-timer.C:
+				return msg
+			}
+		}
 	}
 }
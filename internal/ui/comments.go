@@ -0,0 +1,495 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/azuredevops"
+)
+
+// loadPRThreads loads the comment threads of a pull request as a view request,
+// with inline threads positioned in the diff of its latest iteration
+func (m *Model) loadPRThreads(pr *azuredevops.PullRequest) tea.Cmd {
+	ctx, _ := m.beginViewRequest()
+	m.threadsSeq++
+	seq, client := m.threadsSeq, m.client
+
+	return func() tea.Msg {
+		project, repository := pr.Repository.Project.Name, pr.Repository.Name
+
+		iteration, err := client.GetLatestIteration(ctx, project, repository, pr.ID)
+		if err != nil {
+			return ThreadsLoadedMsg{seq: seq, err: err}
+		}
+
+		threads, err := client.GetPRThreads(ctx, project, repository, pr.ID, iteration)
+		return ThreadsLoadedMsg{seq: seq, threads: threads, err: err}
+	}
+}
+
+// reloadPRThreads loads the threads of the selected PR again when their load
+// was canceled by leaving the details view before they arrived
+func (m *Model) reloadPRThreads() tea.Cmd {
+	if m.selectedPR == nil || m.prThreads != nil || m.threadsErr != nil {
+		return nil
+	}
+	return m.loadPRThreads(m.selectedPR)
+}
+
+// selectPR makes a pull request the selected one and starts loading its
+// threads, and its checks when they haven't been loaded
+func (m *Model) selectPR(pr *azuredevops.PullRequest) tea.Cmd {
+	m.selectedPR = pr
+	m.prThreads = nil
+	m.threadsErr = nil
+	m.selectedThread = 0
+	m.prDetailsViewport.GotoTop()
+	m.refreshPRDetails()
+
+	// PRs past the first maxPRStates of their source get their checks here
+	var loadState tea.Cmd
+	if pr.Checks == nil && pr.ChecksErr == nil {
+		loadState = m.loadPRStates(m.refreshSeq, []azuredevops.PullRequest{*pr})
+	}
+	return tea.Batch(m.loadPRThreads(pr), loadState)
+}
+
+// discussionThreads returns the threads written by people, leaving out the
+// system threads Azure DevOps adds for votes and pushes
+func (m Model) discussionThreads() []azuredevops.PRThread {
+	var threads []azuredevops.PRThread
+	for _, thread := range m.prThreads {
+		if !thread.IsSystem() {
+			threads = append(threads, thread)
+		}
+	}
+	return threads
+}
+
+// getThreadStatusBadge returns a colored label for a thread status
+func getThreadStatusBadge(status string) string {
+	switch status {
+	case azuredevops.ThreadStatusActive, azuredevops.ThreadStatusPending, "":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render("Active") // Yellow
+	case azuredevops.ThreadStatusFixed:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render("Resolved") // Green
+	case azuredevops.ThreadStatusWontFix:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("Won't fix") // Gray
+	case azuredevops.ThreadStatusByDesign:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("By design") // Gray
+	default:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("Closed") // Gray
+	}
+}
+
+// threadLocation describes where a thread is, e.g. "src/app.go:12"
+func threadLocation(thread azuredevops.PRThread) string {
+	path := strings.TrimPrefix(thread.FilePath(), "/")
+	if path == "" {
+		return "General"
+	}
+
+	threadContext := thread.ThreadContext
+	switch {
+	case threadContext.RightFileStart != nil:
+		return fmt.Sprintf("%s:%d", path, threadContext.RightFileStart.Line)
+	case threadContext.LeftFileStart != nil:
+		return fmt.Sprintf("%s:%d (base)", path, threadContext.LeftFileStart.Line)
+	}
+	return path
+}
+
+// formatThread renders a thread as lines: a header followed by each comment
+func formatThread(thread azuredevops.PRThread, indent string, selected bool) []string {
+	borderStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")) // Gray
+	authorStyle := lipgloss.NewStyle().Bold(true)
+	if selected {
+		borderStyle = borderStyle.Copy().Foreground(lipgloss.Color("170"))
+	}
+	textStyle := lipgloss.NewStyle()
+	if thread.IsResolved() {
+		textStyle = textStyle.Foreground(lipgloss.Color("241"))
+	}
+
+	lines := []string{indent + borderStyle.Render("┌ ") + getThreadStatusBadge(thread.Status) +
+		borderStyle.Render(fmt.Sprintf(" · %s · #%d", threadLocation(thread), thread.ID))}
+
+	for _, comment := range thread.Comments {
+		prefix := indent + borderStyle.Render("│ ")
+		if comment.ParentCommentID != 0 {
+			prefix += "  "
+		}
+		for i, text := range strings.Split(strings.TrimRight(comment.Content, "\n"), "\n") {
+			text = displayText(text)
+			if i == 0 {
+				text = authorStyle.Render(comment.Author.DisplayName+": ") + textStyle.Render(text)
+			} else {
+				text = textStyle.Render(text)
+			}
+			lines = append(lines, prefix+text)
+		}
+	}
+
+	return lines
+}
+
+// threadAnchor returns the line a thread is anchored to in the current diff,
+// with the side it is on
+func threadAnchor(thread azuredevops.PRThread) (line int, base bool) {
+	threadContext := thread.ThreadContext
+	switch {
+	case threadContext == nil:
+		return 0, false
+	case threadContext.RightFileStart != nil:
+		return threadContext.RightFileStart.Line, false
+	case threadContext.LeftFileStart != nil:
+		return threadContext.LeftFileStart.Line, true
+	}
+	return 0, false
+}
+
+// insertThreadRows adds the threads of the current file below the rows they
+// are anchored to. Threads on lines that are not shown are listed before the
+// first hunk.
+func (m Model) insertThreadRows(rows []diffRow) []diffRow {
+	byRow := make(map[int][]azuredevops.PRThread)
+	var unanchored []azuredevops.PRThread
+
+	for _, thread := range m.discussionThreads() {
+		path := strings.TrimPrefix(thread.FilePath(), "/")
+		if path == "" || (path != m.currentDiff.NewPath && path != m.currentDiff.OldPath) {
+			continue
+		}
+
+		line, base := threadAnchor(thread)
+		anchor := -1
+		for i, row := range rows {
+			if (!base && row.newLine == line) || (base && row.oldLine == line && row.newLine == 0) {
+				anchor = i
+				break
+			}
+		}
+		if anchor < 0 && base {
+			// Fall back to the unchanged line when the base line wasn't deleted
+			for i, row := range rows {
+				if row.oldLine == line {
+					anchor = i
+					break
+				}
+			}
+		}
+
+		if anchor < 0 {
+			unanchored = append(unanchored, thread)
+		} else {
+			byRow[anchor] = append(byRow[anchor], thread)
+		}
+	}
+
+	if len(byRow) == 0 && len(unanchored) == 0 {
+		return rows
+	}
+
+	// Threads are indented to line up with the code of the unified diff
+	indent := strings.Repeat(" ", 14)
+	threadRows := func(threads []azuredevops.PRThread) []diffRow {
+		var result []diffRow
+		for _, thread := range threads {
+			for _, line := range formatThread(thread, indent, false) {
+				result = append(result, diffRow{text: line, threadID: thread.ID})
+			}
+		}
+		return result
+	}
+
+	var result []diffRow
+	for i, row := range rows {
+		if row.hunk && unanchored != nil {
+			result = append(result, threadRows(unanchored)...)
+			unanchored = nil
+		}
+		result = append(result, row)
+		result = append(result, threadRows(byRow[i])...)
+	}
+	return append(result, threadRows(unanchored)...)
+}
+
+// formatThreadsSection renders the comment threads of the selected PR for the
+// details view and returns the line offset of each thread within it
+func (m Model) formatThreadsSection() (string, []int) {
+	var s strings.Builder
+
+	threads := m.discussionThreads()
+	active := 0
+	for _, thread := range threads {
+		if !thread.IsResolved() {
+			active++
+		}
+	}
+
+	s.WriteString(lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("Comments (%d active, %d resolved):", active, len(threads)-active)))
+	s.WriteString("\n")
+
+	switch {
+	case m.threadsErr != nil:
+		s.WriteString(errorStyle.Render(fmt.Sprintf("Failed to load comments: %v", m.threadsErr)))
+		s.WriteString("\n")
+		return s.String(), nil
+	case m.prThreads == nil:
+		s.WriteString("Loading comments...\n")
+		return s.String(), nil
+	case len(threads) == 0:
+		s.WriteString(lipgloss.NewStyle().Italic(true).Foreground(lipgloss.Color("241")).Render("(No comments)"))
+		s.WriteString("\n")
+		return s.String(), nil
+	}
+
+	var offsets []int
+	line := 1
+	for i, thread := range threads {
+		offsets = append(offsets, line)
+		selected := i == m.selectedThread
+
+		marker := "  "
+		if selected {
+			marker = lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true).Render("▶ ")
+		}
+		for j, text := range formatThread(thread, "", selected) {
+			if j == 0 {
+				text = marker + text
+			} else {
+				text = "  " + text
+			}
+			s.WriteString(text + "\n")
+			line++
+		}
+		s.WriteString("\n")
+		line++
+	}
+
+	return s.String(), offsets
+}
+
+// moveSelectedThread selects the next (direction 1) or previous (direction -1)
+// thread in the details view and scrolls it into view
+func (m *Model) moveSelectedThread(direction int) {
+	threads := m.discussionThreads()
+	next := m.selectedThread + direction
+	if next < 0 || next >= len(threads) {
+		return
+	}
+	m.selectedThread = next
+	m.refreshPRDetails()
+
+	offset := m.detailsThreadOffset + m.detailsThreadOffsets[next]
+	if offset < m.prDetailsViewport.YOffset || offset >= m.prDetailsViewport.YOffset+m.prDetailsViewport.Height {
+		m.prDetailsViewport.SetYOffset(offset)
+	}
+}
+
+// selectedThreadID returns the thread the current view has selected: the
+// thread under the cursor in the diff view, or the highlighted thread in the
+// details view. It returns 0 when no thread is selected.
+func (m Model) selectedThreadID() int {
+	switch m.view {
+	case ViewFileDiff:
+		if m.diffCursor < len(m.diffRows) {
+			return m.diffRows[m.diffCursor].threadID
+		}
+	case ViewPRDetails:
+		threads := m.discussionThreads()
+		if m.selectedThread < len(threads) {
+			return threads[m.selectedThread].ID
+		}
+	}
+	return 0
+}
+
+// findThread returns the loaded thread with the given ID
+func (m Model) findThread(threadID int) (azuredevops.PRThread, bool) {
+	for _, thread := range m.prThreads {
+		if thread.ID == threadID {
+			return thread, true
+		}
+	}
+	return azuredevops.PRThread{}, false
+}
+
+// diffCursorContext returns the thread context for a new thread on the line
+// under the diff cursor
+func (m Model) diffCursorContext() (*azuredevops.ThreadContext, error) {
+	if m.diffCursor >= len(m.diffRows) {
+		return nil, fmt.Errorf("select a line to comment on")
+	}
+	row := m.diffRows[m.diffCursor]
+	if row.threadID != 0 || (row.newLine == 0 && row.oldLine == 0) {
+		return nil, fmt.Errorf("select a line of code to comment on")
+	}
+
+	threadContext := &azuredevops.ThreadContext{FilePath: "/" + m.currentDiff.NewPath}
+	if row.newLine > 0 {
+		length := lineLength(m.currentDiff.NewText, row.newLine)
+		threadContext.RightFileStart = &azuredevops.FilePosition{Line: row.newLine, Offset: 1}
+		threadContext.RightFileEnd = &azuredevops.FilePosition{Line: row.newLine, Offset: length + 1}
+	} else {
+		length := lineLength(m.currentDiff.OldText, row.oldLine)
+		threadContext.LeftFileStart = &azuredevops.FilePosition{Line: row.oldLine, Offset: 1}
+		threadContext.LeftFileEnd = &azuredevops.FilePosition{Line: row.oldLine, Offset: length + 1}
+	}
+	return threadContext, nil
+}
+
+// lineLength returns the length in characters of a 1-based line of text
+func lineLength(text string, line int) int {
+	lines := strings.Split(text, "\n")
+	if line < 1 || line > len(lines) {
+		return 0
+	}
+	return len([]rune(strings.TrimSuffix(lines[line-1], "\r")))
+}
+
+// startCompose opens the comment editor. A threadID of 0 starts a new thread,
+// on the given context or on the PR as a whole when it is nil.
+func (m *Model) startCompose(title string, threadID int, threadContext *azuredevops.ThreadContext) tea.Cmd {
+	m.composing = true
+	m.composeTitle = title
+	m.composeThreadID = threadID
+	m.composeContext = threadContext
+	m.composeInput.Reset()
+	m.composeInput.SetWidth(m.width - 4)
+	return m.composeInput.Focus()
+}
+
+// updateCompose handles keys while the comment editor is open
+func (m Model) updateCompose(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+s":
+		content := strings.TrimSpace(m.composeInput.Value())
+		if content == "" || m.selectedPR == nil {
+			return m, nil
+		}
+		m.composing = false
+		m.composeInput.Blur()
+		m.notice = "Posting comment..."
+		return m, m.postComment(m.selectedPR, m.composeThreadID, m.composeContext, content)
+
+	case "esc":
+		m.composing = false
+		m.composeInput.Blur()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.composeInput, cmd = m.composeInput.Update(msg)
+	return m, cmd
+}
+
+// renderCompose renders the comment editor in place of a view's status bar
+func (m Model) renderCompose() string {
+	var s strings.Builder
+	s.WriteString(lipgloss.NewStyle().Bold(true).Render(m.composeTitle))
+	s.WriteString("\n")
+	s.WriteString(m.composeInput.View())
+	s.WriteString("\n")
+	s.WriteString(statusStyle.Render("Press 'ctrl+s' to post, 'esc' to cancel"))
+	return s.String()
+}
+
+// postComment posts a reply to a thread, or starts a new thread when threadID is 0
+func (m Model) postComment(pr *azuredevops.PullRequest, threadID int, threadContext *azuredevops.ThreadContext, content string) tea.Cmd {
+	// Tie inline threads to the file and iteration shown in the diff view
+	var prContext *azuredevops.PRThreadContext
+	if threadContext != nil && m.prIteration > 0 {
+		prContext = &azuredevops.PRThreadContext{
+			ChangeTrackingID: m.prFiles[m.diffIndex].ChangeTrackingID,
+			IterationContext: azuredevops.IterationContext{
+				FirstComparingIteration:  1,
+				SecondComparingIteration: m.prIteration,
+			},
+		}
+	}
+
+	// Replies go to the first comment of the thread that is still there
+	parentCommentID := 0
+	if thread, ok := m.findThread(threadID); ok && len(thread.Comments) > 0 {
+		parentCommentID = thread.Comments[0].ID
+	}
+
+	// Posting isn't canceled by leaving the view, only by quitting
+	ctx := m.ctx
+	return func() tea.Msg {
+		project, repository := pr.Repository.Project.Name, pr.Repository.Name
+
+		if threadID == 0 {
+			thread, err := m.client.CreatePRThread(ctx, project, repository, pr.ID, threadContext, prContext, content)
+			if err != nil {
+				return ThreadChangedMsg{prID: pr.ID, err: err}
+			}
+			return ThreadChangedMsg{prID: pr.ID, threadID: thread.ID, thread: &thread}
+		}
+
+		comment, err := m.client.ReplyToPRThread(ctx, project, repository, pr.ID, threadID, parentCommentID, content)
+		if err != nil {
+			return ThreadChangedMsg{prID: pr.ID, err: err}
+		}
+		return ThreadChangedMsg{prID: pr.ID, threadID: threadID, comment: &comment}
+	}
+}
+
+// toggleThreadStatus resolves an active thread or reactivates a resolved one.
+// Like posting, it doesn't take over the view's request, which may still be
+// loading the diff.
+func (m Model) toggleThreadStatus(threadID int) tea.Cmd {
+	thread, ok := m.findThread(threadID)
+	if !ok || m.selectedPR == nil {
+		return nil
+	}
+
+	status := azuredevops.ThreadStatusFixed
+	if thread.IsResolved() {
+		status = azuredevops.ThreadStatusActive
+	}
+
+	pr, client, ctx := m.selectedPR, m.client, m.ctx
+	return func() tea.Msg {
+		_, err := client.SetPRThreadStatus(ctx, pr.Repository.Project.Name, pr.Repository.Name, pr.ID, threadID, status)
+		if err != nil {
+			return ThreadChangedMsg{prID: pr.ID, err: err}
+		}
+		return ThreadChangedMsg{prID: pr.ID, threadID: threadID, status: status}
+	}
+}
+
+// applyThreadChange updates the loaded threads with a posted comment, a new
+// thread or a status change and re-renders the views that show them
+func (m *Model) applyThreadChange(msg ThreadChangedMsg) {
+	switch {
+	case msg.thread != nil:
+		m.prThreads = append(m.prThreads, *msg.thread)
+		m.notice = "Comment posted"
+	case msg.comment != nil:
+		for i := range m.prThreads {
+			if m.prThreads[i].ID == msg.threadID {
+				m.prThreads[i].Comments = append(m.prThreads[i].Comments, *msg.comment)
+			}
+		}
+		m.notice = "Reply posted"
+	case msg.status != "":
+		for i := range m.prThreads {
+			if m.prThreads[i].ID == msg.threadID {
+				m.prThreads[i].Status = msg.status
+			}
+		}
+		m.notice = "Thread reactivated"
+		if msg.status == azuredevops.ThreadStatusFixed {
+			m.notice = "Thread resolved"
+		}
+	}
+
+	m.refreshPRDetails()
+	if m.view == ViewFileDiff {
+		m.refreshDiffView()
+	}
+}
@@ -0,0 +1,256 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/azuredevops"
+)
+
+// completionDialog holds the choices of the PR completion dialog
+type completionDialog struct {
+	saved              azuredevops.CompletionOptions // The PR's saved options, kept except where chosen in the dialog
+	strategy           int                           // Index into azuredevops.MergeStrategies
+	deleteSourceBranch bool
+	policies           []azuredevops.PolicyEvaluation
+	policiesErr        error
+	loadingPolicies    bool
+}
+
+// options returns the PR's saved completion options with the choices made in
+// the dialog applied
+func (d completionDialog) options() azuredevops.CompletionOptions {
+	options := d.saved
+	options.MergeStrategy = azuredevops.MergeStrategies[d.strategy]
+	options.DeleteSourceBranch = d.deleteSourceBranch
+	return options
+}
+
+// blockingPolicies returns the policies that currently prevent completion
+func (d completionDialog) blockingPolicies() []azuredevops.PolicyEvaluation {
+	var blocking []azuredevops.PolicyEvaluation
+	for _, policy := range d.policies {
+		if policy.Blocks() {
+			blocking = append(blocking, policy)
+		}
+	}
+	return blocking
+}
+
+// openCompletion opens the completion dialog for the selected PR, starting
+// from the PR's saved completion options, and loads its policy evaluations
+func (m *Model) openCompletion() tea.Cmd {
+	pr := m.selectedPR
+	dialog := &completionDialog{loadingPolicies: true}
+	if pr.CompletionOptions != nil {
+		dialog.saved = *pr.CompletionOptions
+		dialog.deleteSourceBranch = pr.CompletionOptions.DeleteSourceBranch
+		for i, strategy := range azuredevops.MergeStrategies {
+			if strategy == pr.CompletionOptions.MergeStrategy {
+				dialog.strategy = i
+			}
+		}
+	}
+	m.completion = dialog
+
+	return func() tea.Msg {
+		policies, err := m.client.GetPolicyEvaluations(m.ctx, pr.Repository.Project.Name, pr.Repository.Project.ID, pr.ID)
+		return PoliciesLoadedMsg{prID: pr.ID, policies: policies, err: err}
+	}
+}
+
+// updateCompletion handles keys while the completion dialog is open
+func (m Model) updateCompletion(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	pr := m.selectedPR
+	dialog := *m.completion
+
+	switch msg.String() {
+	case "esc":
+		m.completion = nil
+		return m, nil
+
+	case "s":
+		dialog.strategy = (dialog.strategy + 1) % len(azuredevops.MergeStrategies)
+
+	case "d":
+		dialog.deleteSourceBranch = !dialog.deleteSourceBranch
+
+	case "enter":
+		// Without the policies the prompt can't warn about blocking ones
+		if dialog.loadingPolicies {
+			return m, nil
+		}
+		question := fmt.Sprintf("Complete PR #%d now using %s?", pr.ID, azuredevops.MergeStrategyLabel(azuredevops.MergeStrategies[dialog.strategy]))
+		if blocking := len(dialog.blockingPolicies()); blocking > 0 {
+			question = fmt.Sprintf("%d blocking policies are not met. Try to complete PR #%d anyway?", blocking, pr.ID)
+		}
+		// Complete at the commit that was loaded, not whatever the branch is at now
+		sourceCommitID := ""
+		if pr.LastMergeSourceCommit != nil {
+			sourceCommitID = pr.LastMergeSourceCommit.CommitID
+		}
+		m.confirm(question, m.changePR(pr, "Completed", func(ctx context.Context, project, repository string) (azuredevops.PullRequest, error) {
+			return m.client.CompletePullRequest(ctx, project, repository, pr.ID, sourceCommitID, dialog.options())
+		}))
+
+	case "a":
+		if pr.AutoCompleteSetBy != nil {
+			m.confirm(fmt.Sprintf("Cancel auto-complete of PR #%d?", pr.ID), m.changePR(pr, "Auto-complete canceled", func(ctx context.Context, project, repository string) (azuredevops.PullRequest, error) {
+				return m.client.CancelAutoComplete(ctx, project, repository, pr.ID)
+			}))
+			break
+		}
+		m.confirm(fmt.Sprintf("Set PR #%d to complete automatically when all policies pass?", pr.ID), m.changePR(pr, "Auto-complete set", func(ctx context.Context, project, repository string) (azuredevops.PullRequest, error) {
+			identity, err := m.client.CurrentUser(ctx)
+			if err != nil {
+				return azuredevops.PullRequest{}, err
+			}
+			return m.client.SetAutoComplete(ctx, project, repository, pr.ID, identity.ID, dialog.options())
+		}))
+	}
+
+	m.completion = &dialog
+	return m, nil
+}
+
+// changePR returns a command that applies a change to a pull request and
+// reports the updated PR with a notice on success. The change isn't canceled
+// by leaving the view, only by quitting.
+func (m Model) changePR(pr *azuredevops.PullRequest, notice string, change func(ctx context.Context, project, repository string) (azuredevops.PullRequest, error)) tea.Cmd {
+	return func() tea.Msg {
+		updated, err := change(m.ctx, pr.Repository.Project.Name, pr.Repository.Name)
+		return PRUpdatedMsg{
+			prID:           pr.ID,
+			repositoryName: pr.Repository.Name,
+			pr:             updated,
+			notice:         fmt.Sprintf("%s PR #%d", notice, pr.ID),
+			err:            err,
+		}
+	}
+}
+
+// confirmAbandon asks before abandoning the selected PR
+func (m *Model) confirmAbandon() {
+	pr := m.selectedPR
+	m.confirm(fmt.Sprintf("Abandon PR #%d?", pr.ID), m.changePR(pr, "Abandoned", func(ctx context.Context, project, repository string) (azuredevops.PullRequest, error) {
+		return m.client.AbandonPullRequest(ctx, project, repository, pr.ID)
+	}))
+}
+
+// confirmPublish asks before publishing the selected draft PR
+func (m *Model) confirmPublish() {
+	pr := m.selectedPR
+	if !pr.IsDraft {
+		m.notice = fmt.Sprintf("PR #%d is not a draft", pr.ID)
+		return
+	}
+	m.confirm(fmt.Sprintf("Publish draft PR #%d for review?", pr.ID), m.changePR(pr, "Published", func(ctx context.Context, project, repository string) (azuredevops.PullRequest, error) {
+		return m.client.PublishPullRequest(ctx, project, repository, pr.ID)
+	}))
+}
+
+// applyPRUpdate shows a PR changed from the dashboard. PRs that are no longer
+// active are removed from the list but stay shown in the details view.
+func (m *Model) applyPRUpdate(msg PRUpdatedMsg) {
+	m.completion = nil
+	m.notice = msg.notice
+
+	m.updatePR(msg.repositoryName, msg.prID, func(pr *azuredevops.PullRequest) {
+		checks, checksErr, voteReset := pr.Checks, pr.ChecksErr, pr.VoteReset
+		*pr = msg.pr
+		pr.Checks, pr.ChecksErr, pr.VoteReset = checks, checksErr, voteReset
+	})
+	if msg.pr.Status != "" && msg.pr.Status != "active" {
+		m.removePR(msg.repositoryName, msg.prID)
+	}
+}
+
+// removePR removes a pull request from the loaded sources and the list
+func (m *Model) removePR(repository string, prID int) {
+	for index, prs := range m.sourcePRs {
+		var kept []azuredevops.PullRequest
+		for _, pr := range prs {
+			if pr.ID != prID || pr.Repository.Name != repository {
+				kept = append(kept, pr)
+			}
+		}
+		m.sourcePRs[index] = kept
+	}
+	m.updateLists()
+}
+
+// getPolicyStatusIcon returns a colored icon for a policy evaluation
+func getPolicyStatusIcon(policy azuredevops.PolicyEvaluation) string {
+	switch policy.Status {
+	case azuredevops.PolicyStatusApproved:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render("✓") // Green
+	case azuredevops.PolicyStatusRejected, azuredevops.PolicyStatusBroken:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render("✗") // Red
+	case azuredevops.PolicyStatusRunning, azuredevops.PolicyStatusQueued:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render("●") // Yellow
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("○") // Gray
+}
+
+// renderCompletion renders the completion dialog in place of the PR details
+func (m Model) renderCompletion() string {
+	var s strings.Builder
+	dialog := m.completion
+	pr := m.selectedPR
+	labelStyle := lipgloss.NewStyle().Bold(true)
+	keyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true)
+
+	s.WriteString(labelStyle.Render("Complete pull request"))
+	s.WriteString("\n\n")
+
+	s.WriteString(fmt.Sprintf("%s Merge type: %s\n", keyStyle.Render("s"), azuredevops.MergeStrategyLabel(azuredevops.MergeStrategies[dialog.strategy])))
+	deleteBranch := "no"
+	if dialog.deleteSourceBranch {
+		deleteBranch = "yes"
+	}
+	s.WriteString(fmt.Sprintf("%s Delete %s after merging: %s\n", keyStyle.Render("d"), strings.TrimPrefix(pr.SourceRefName, "refs/heads/"), deleteBranch))
+
+	autoComplete := "off"
+	if pr.AutoCompleteSetBy != nil {
+		autoComplete = "set by " + pr.AutoCompleteSetBy.DisplayName
+	}
+	s.WriteString(fmt.Sprintf("  Auto-complete: %s\n\n", autoComplete))
+
+	s.WriteString(labelStyle.Render("Policies:"))
+	s.WriteString("\n")
+	switch {
+	case dialog.loadingPolicies:
+		s.WriteString("Loading policies...\n")
+	case dialog.policiesErr != nil:
+		s.WriteString(errorStyle.Render(fmt.Sprintf("Failed to load policies: %v", dialog.policiesErr)))
+		s.WriteString("\n")
+	case len(dialog.policies) == 0:
+		s.WriteString("No branch policies apply to this PR\n")
+	default:
+		for _, policy := range dialog.policies {
+			line := fmt.Sprintf("%s %s (%s)", getPolicyStatusIcon(policy), policy.Name(), policy.Status)
+			if policy.Blocks() {
+				line += errorStyle.Render(" blocking")
+			} else if !policy.Configuration.IsBlocking {
+				line += lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(" optional")
+			}
+			s.WriteString(line + "\n")
+		}
+	}
+
+	s.WriteString("\n")
+	autoCompleteAction := "set auto-complete"
+	if pr.AutoCompleteSetBy != nil {
+		autoCompleteAction = "cancel auto-complete"
+	}
+	completeAction := "'enter' to complete now"
+	if dialog.loadingPolicies {
+		completeAction = "'enter' to complete once policies have loaded"
+	}
+	s.WriteString(statusStyle.Render(fmt.Sprintf("Press %s, 'a' to %s, 'esc' to close", completeAction, autoCompleteAction)))
+
+	return s.String()
+}
@@ -0,0 +1,413 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/azuredevops"
+)
+
+// minSideBySideWidth is the narrowest terminal that can show a split diff
+const minSideBySideWidth = 100
+
+// DiffMode selects how the file diff view lays out a diff
+type DiffMode int
+
+const (
+	DiffModeUnified DiffMode = iota
+	DiffModeSideBySide
+	DiffModeFullFile
+)
+
+// Backgrounds for changed lines, layered under syntax highlighting
+var (
+	addedBackground   = lipgloss.Color("22") // dark green
+	deletedBackground = lipgloss.Color("52") // dark red
+)
+
+// sideBySideRow is one row of a split diff, either side may be empty
+type sideBySideRow struct {
+	left  *azuredevops.DiffLine
+	right *azuredevops.DiffLine
+}
+
+// diffRow is a rendered row of the diff view and the file lines it shows
+type diffRow struct {
+	text     string
+	oldLine  int  // Base version line shown on the row, 0 if none
+	newLine  int  // PR version line shown on the row, 0 if none
+	hunk     bool // The row starts a hunk
+	threadID int  // Comment thread shown on the row, 0 if none
+}
+
+// refreshDiffView renders the current diff into the diff viewport using the
+// selected mode, falling back to unified when the terminal is too narrow
+func (m *Model) refreshDiffView() {
+	var rows []diffRow
+	switch {
+	case m.diffMode == DiffModeFullFile:
+		rows = m.formatFullFile(m.currentDiff)
+	case m.diffMode == DiffModeSideBySide && m.width >= minSideBySideWidth:
+		rows = m.formatSideBySideDiff(m.currentDiff)
+	default:
+		rows = m.formatDiff(m.currentDiff)
+	}
+	m.diffRows = m.insertThreadRows(rows)
+	m.diffSearch.find(m.diffRowTexts())
+
+	m.diffHunkOffsets = nil
+	for i, row := range m.diffRows {
+		if row.hunk {
+			m.diffHunkOffsets = append(m.diffHunkOffsets, i)
+		}
+	}
+
+	if m.diffCursor >= len(m.diffRows) {
+		m.diffCursor = len(m.diffRows) - 1
+	}
+	if m.diffCursor < 0 {
+		m.diffCursor = 0
+	}
+	m.renderDiffRows()
+}
+
+// diffRowTexts returns the rendered text of each diff row
+func (m Model) diffRowTexts() []string {
+	texts := make([]string, len(m.diffRows))
+	for i, row := range m.diffRows {
+		texts[i] = row.text
+	}
+	return texts
+}
+
+// renderDiffRows sets the diff viewport content, marking the cursor row and
+// highlighting the matches of the search
+func (m *Model) renderDiffRows() {
+	cursorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true)
+
+	lines := m.diffSearch.highlight(m.diffRowTexts())
+	for i := range lines {
+		marker := " "
+		if i == m.diffCursor {
+			marker = cursorStyle.Render("▶")
+		}
+		lines[i] = marker + lines[i]
+	}
+	m.diffViewport.SetContent(strings.Join(lines, "\n"))
+}
+
+// moveDiffCursor moves the diff cursor to a row, scrolling it into view
+func (m *Model) moveDiffCursor(row int) {
+	if row >= len(m.diffRows) {
+		row = len(m.diffRows) - 1
+	}
+	if row < 0 {
+		row = 0
+	}
+	m.diffCursor = row
+
+	if row < m.diffViewport.YOffset {
+		m.diffViewport.SetYOffset(row)
+	} else if row >= m.diffViewport.YOffset+m.diffViewport.Height {
+		m.diffViewport.SetYOffset(row - m.diffViewport.Height + 1)
+	}
+	m.renderDiffRows()
+}
+
+// followDiffViewport keeps the cursor on screen after the viewport scrolled
+// by a page or with the mouse
+func (m *Model) followDiffViewport() {
+	top := m.diffViewport.YOffset
+	bottom := top + m.diffViewport.Height - 1
+	switch {
+	case m.diffCursor < top:
+		m.diffCursor = top
+	case m.diffCursor > bottom:
+		m.diffCursor = bottom
+	default:
+		return
+	}
+	m.renderDiffRows()
+}
+
+// handleDiffCursorKey moves the diff cursor for line-wise movement keys and
+// reports whether the key was handled
+func (m *Model) handleDiffCursorKey(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "up", "k":
+		m.moveDiffCursor(m.diffCursor - 1)
+	case "down", "j":
+		m.moveDiffCursor(m.diffCursor + 1)
+	default:
+		return false
+	}
+	return true
+}
+
+// scrollToHunk moves the cursor to the next (direction 1) or previous
+// (direction -1) hunk and scrolls it to the top of the viewport
+func (m *Model) scrollToHunk(direction int) {
+	current := m.diffCursor
+	target := -1
+	if direction > 0 {
+		for _, offset := range m.diffHunkOffsets {
+			if offset > current {
+				target = offset
+				break
+			}
+		}
+	} else {
+		for i := len(m.diffHunkOffsets) - 1; i >= 0; i-- {
+			if m.diffHunkOffsets[i] < current {
+				target = m.diffHunkOffsets[i]
+				break
+			}
+		}
+	}
+	if target < 0 {
+		return
+	}
+
+	m.diffViewport.SetYOffset(target)
+	m.moveDiffCursor(target)
+}
+
+// diffModeLabel describes the active diff mode for the status bar
+func (m Model) diffModeLabel() string {
+	switch m.diffMode {
+	case DiffModeFullFile:
+		return "Full file"
+	case DiffModeSideBySide:
+		if m.width < minSideBySideWidth {
+			return "Unified (too narrow for side-by-side)"
+		}
+		return "Side-by-side"
+	}
+	return "Unified"
+}
+
+// formatFullFile renders the complete PR version of a file with syntax
+// highlighting, marking added lines and the places where lines were deleted.
+// The first changed line of each hunk is marked as the start of the hunk.
+func (m Model) formatFullFile(diff azuredevops.FileDiff) []diffRow {
+	var rows []diffRow
+
+	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true)     // bright blue
+	gutterStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))               // gray
+	addedMarker := lipgloss.NewStyle().Foreground(lipgloss.Color("46")).Render("+")    // bright green
+	deletedMarker := lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render("▾") // bright red
+
+	rows = append(rows, diffRow{text: headerStyle.Render(fmt.Sprintf("b/%s", diff.NewPath))})
+
+	switch {
+	case diff.IsBinary:
+		return append(rows, diffRow{text: "Binary file"})
+	case diff.IsDeleted:
+		return append(rows, diffRow{text: "File deleted in this PR"})
+	}
+
+	// Collect added lines and the lines that follow a deletion
+	added := make(map[int]bool)
+	deletedBefore := make(map[int]int)
+	hunkStarts := make(map[int]bool)
+	for _, hunk := range diff.Hunks {
+		pendingDeletes := 0
+		firstChange := 0
+		for _, line := range hunk.Lines {
+			if firstChange == 0 && line.Kind != azuredevops.DiffContext {
+				firstChange = line.NewLine
+				if firstChange == 0 {
+					firstChange = hunk.NewStart + hunk.NewLines
+				}
+			}
+			switch line.Kind {
+			case azuredevops.DiffDeleted:
+				pendingDeletes++
+				continue
+			case azuredevops.DiffAdded:
+				added[line.NewLine] = true
+			}
+			if pendingDeletes > 0 {
+				deletedBefore[line.NewLine] += pendingDeletes
+				pendingDeletes = 0
+			}
+		}
+		if pendingDeletes > 0 {
+			// Deleted lines at the end of the hunk are marked on the next line
+			deletedBefore[hunk.NewStart+hunk.NewLines] += pendingDeletes
+		}
+		hunkStarts[firstChange] = true
+	}
+
+	lines := strings.Split(strings.TrimSuffix(diff.NewText, "\n"), "\n")
+	for i, text := range lines {
+		lineNumber := i + 1
+
+		marker := " "
+		if deletedBefore[lineNumber] > 0 {
+			marker = deletedMarker
+		}
+
+		row := diffRow{newLine: lineNumber, hunk: hunkStarts[lineNumber]}
+		if added[lineNumber] {
+			row.text = gutterStyle.Render(fmt.Sprintf("%5d ", lineNumber)) + marker + addedMarker + " " +
+				renderCode(m.diffHighlight.newLines, lineNumber, text, addedBackground)
+		} else {
+			row.text = gutterStyle.Render(fmt.Sprintf("%5d ", lineNumber)) + marker + "  " +
+				renderCode(m.diffHighlight.newLines, lineNumber, text, nil)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// formatSideBySideDiff renders a diff with the base version on the left and the
+// PR version on the right, aligned by line, with changed words highlighted
+func (m Model) formatSideBySideDiff(diff azuredevops.FileDiff) []diffRow {
+	var rows []diffRow
+
+	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true) // bright blue
+	hunkStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("141"))             // purple/magenta
+
+	rows = append(rows, diffRow{text: headerStyle.Render(fmt.Sprintf("a/%s", diff.OldPath)) + " → " +
+		headerStyle.Render(fmt.Sprintf("b/%s", diff.NewPath))})
+
+	if diff.IsBinary {
+		return append(rows, diffRow{text: hunkStyle.Render("Binary files differ")})
+	}
+	if len(diff.Hunks) == 0 {
+		return append(rows, diffRow{text: hunkStyle.Render("No content changes")})
+	}
+
+	// Each side gets a line number gutter, its text and a separator, after
+	// the cursor column
+	columnWidth := (m.diffViewport.Width - 4) / 2
+	textWidth := columnWidth - 7
+	if textWidth < 10 {
+		textWidth = 10
+	}
+
+	for _, hunk := range diff.Hunks {
+		rows = append(rows, diffRow{text: hunkStyle.Render(hunk.Header()), hunk: true})
+		for _, pair := range pairDiffLines(hunk.Lines) {
+			left, right := renderSideBySideRow(pair, textWidth, m.diffHighlight)
+			row := diffRow{text: left + " │ " + right}
+			if pair.left != nil {
+				row.oldLine = pair.left.OldLine
+			}
+			if pair.right != nil {
+				row.newLine = pair.right.NewLine
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
+
+// pairDiffLines aligns the lines of a hunk into rows. Context lines appear on
+// both sides; a run of deletions is paired line by line with the additions
+// that follow it.
+func pairDiffLines(lines []azuredevops.DiffLine) []sideBySideRow {
+	var rows []sideBySideRow
+
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == azuredevops.DiffContext {
+			rows = append(rows, sideBySideRow{left: &lines[i], right: &lines[i]})
+			i++
+			continue
+		}
+
+		var deleted, added []*azuredevops.DiffLine
+		for i < len(lines) && lines[i].Kind == azuredevops.DiffDeleted {
+			deleted = append(deleted, &lines[i])
+			i++
+		}
+		for i < len(lines) && lines[i].Kind == azuredevops.DiffAdded {
+			added = append(added, &lines[i])
+			i++
+		}
+
+		for j := 0; j < len(deleted) || j < len(added); j++ {
+			var row sideBySideRow
+			if j < len(deleted) {
+				row.left = deleted[j]
+			}
+			if j < len(added) {
+				row.right = added[j]
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
+
+// renderSideBySideRow renders both halves of a split diff row at a fixed width
+func renderSideBySideRow(row sideBySideRow, textWidth int, highlight fileHighlight) (string, string) {
+	gutterStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))                                        // gray
+	deletedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))                                       // bright red
+	addedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("46"))                                          // bright green
+	deletedWordStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("231")).Background(lipgloss.Color("124")) // white on dark red
+	addedWordStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("231")).Background(lipgloss.Color("28"))    // white on dark green
+	cellStyle := lipgloss.NewStyle().Width(textWidth).MaxWidth(textWidth).MaxHeight(1)
+
+	var leftText, rightText string
+	switch {
+	case row.left != nil && row.right != nil && row.left.Kind == azuredevops.DiffDeleted:
+		// A changed line: highlight the words that differ
+		oldSegments, newSegments := azuredevops.DiffWords(displayText(row.left.Text), displayText(row.right.Text))
+		leftText = renderSegments(oldSegments, deletedStyle, deletedWordStyle)
+		rightText = renderSegments(newSegments, addedStyle, addedWordStyle)
+	default:
+		// Context lines and unpaired changes keep their syntax highlighting
+		if row.left != nil {
+			var background lipgloss.TerminalColor
+			if row.left.Kind == azuredevops.DiffDeleted {
+				background = deletedBackground
+			}
+			leftText = renderCode(highlight.oldLines, row.left.OldLine, row.left.Text, background)
+		}
+		if row.right != nil {
+			var background lipgloss.TerminalColor
+			if row.right.Kind == azuredevops.DiffAdded {
+				background = addedBackground
+			}
+			rightText = renderCode(highlight.newLines, row.right.NewLine, row.right.Text, background)
+		}
+	}
+
+	leftNumber, rightNumber := "", ""
+	if row.left != nil {
+		leftNumber = fmt.Sprintf("%d", row.left.OldLine)
+	}
+	if row.right != nil {
+		rightNumber = fmt.Sprintf("%d", row.right.NewLine)
+	}
+
+	left := gutterStyle.Render(fmt.Sprintf("%5s ", leftNumber)) + cellStyle.Render(leftText)
+	right := gutterStyle.Render(fmt.Sprintf("%5s ", rightNumber)) + cellStyle.Render(rightText)
+	return left, right
+}
+
+// renderSegments renders word diff segments, emphasizing the changed ones
+func renderSegments(segments []azuredevops.DiffSegment, style, changedStyle lipgloss.Style) string {
+	var result strings.Builder
+	for _, segment := range segments {
+		if segment.Changed {
+			result.WriteString(changedStyle.Render(segment.Text))
+		} else {
+			result.WriteString(style.Render(segment.Text))
+		}
+	}
+	return result.String()
+}
+
+// displayText prepares a line of file content for fixed-width display
+func displayText(text string) string {
+	text = strings.TrimSuffix(text, "\r")
+	return strings.ReplaceAll(text, "\t", "    ")
+}
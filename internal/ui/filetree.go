@@ -0,0 +1,406 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/azuredevops"
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/glob"
+)
+
+// fileTreeNode is a directory or file in the tree of files changed by a PR
+type fileTreeNode struct {
+	name      string // Path element, or several joined for single-child directories
+	path      string // Full path without leading slash
+	children  []*fileTreeNode
+	fileIndex int // Index in prFiles, -1 for directories
+}
+
+// lineStats holds the added and deleted line counts of one or more files
+type lineStats struct {
+	added   int
+	deleted int
+	binary  bool
+	pending int // Files whose diff has not been loaded yet
+}
+
+const (
+	// maxDiffStatsFiles is the most files whose line counts are loaded when the
+	// files view opens; the rest only get counts once their diff is viewed
+	maxDiffStatsFiles = 300
+
+	// diffStatsBatchInterval is how long line counts are collected before the
+	// file tree is rebuilt with them
+	diffStatsBatchInterval = 100 * time.Millisecond
+)
+
+// fileLineStats holds the line counts of a single file
+type fileLineStats struct {
+	path  string
+	stats lineStats
+}
+
+// fileTreeItem is a row of the changed files tree for use in a list
+type fileTreeItem struct {
+	depth     int
+	name      string
+	path      string
+	isDir     bool
+	collapsed bool
+	fileIndex int
+	change    azuredevops.PRChange
+	stats     lineStats
+}
+
+func (i fileTreeItem) FilterValue() string {
+	return i.path
+}
+
+func (i fileTreeItem) Title() string {
+	indent := strings.Repeat("  ", i.depth)
+
+	if i.isDir {
+		arrow := "▾"
+		if i.collapsed {
+			arrow = "▸"
+		}
+		dirStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39")) // bright blue
+		return fmt.Sprintf("%s%s %s %s", indent, arrow, dirStyle.Render(i.name+"/"), formatLineStats(i.stats))
+	}
+
+	title := fmt.Sprintf("%s%s %s %s", indent, getChangeTypeBadge(i.change), i.name, formatLineStats(i.stats))
+	if i.change.HasChangeType(azuredevops.ChangeTypeRename) && i.change.OriginalPath != "" {
+		title += lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(" ← " + strings.TrimPrefix(i.change.OriginalPath, "/"))
+	}
+	return title
+}
+
+func (i fileTreeItem) Description() string {
+	return ""
+}
+
+// formatLineStats renders line counts as colored +added -deleted
+func formatLineStats(stats lineStats) string {
+	addedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("10"))  // Green
+	deletedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9")) // Red
+	mutedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")) // Gray
+
+	var parts []string
+	if stats.added > 0 || stats.deleted > 0 {
+		parts = append(parts, addedStyle.Render(fmt.Sprintf("+%d", stats.added))+" "+deletedStyle.Render(fmt.Sprintf("-%d", stats.deleted)))
+	}
+	if stats.binary {
+		parts = append(parts, mutedStyle.Render("binary"))
+	}
+	if stats.pending > 0 {
+		parts = append(parts, mutedStyle.Render("…"))
+	}
+	return strings.Join(parts, " ")
+}
+
+// sortFilesForTree orders changed files the way the tree lists them, with
+// directories before files at each level
+func sortFilesForTree(files []azuredevops.PRChange) {
+	sort.SliceStable(files, func(a, b int) bool {
+		left := strings.Split(strings.TrimPrefix(files[a].Item.Path, "/"), "/")
+		right := strings.Split(strings.TrimPrefix(files[b].Item.Path, "/"), "/")
+
+		for i := 0; i < len(left) && i < len(right); i++ {
+			if left[i] == right[i] {
+				continue
+			}
+			leftIsDir, rightIsDir := i < len(left)-1, i < len(right)-1
+			if leftIsDir != rightIsDir {
+				return leftIsDir
+			}
+			return left[i] < right[i]
+		}
+		return len(left) > len(right)
+	})
+}
+
+// buildFileTree builds the directory tree of the visible files. Files must be
+// sorted with sortFilesForTree so that the children come out in order.
+func (m Model) buildFileTree() *fileTreeNode {
+	root := &fileTreeNode{fileIndex: -1}
+
+	for i, change := range m.prFiles {
+		if !m.fileVisible(i) {
+			continue
+		}
+
+		elements := strings.Split(strings.TrimPrefix(change.Item.Path, "/"), "/")
+		node := root
+		for depth, element := range elements[:len(elements)-1] {
+			var dir *fileTreeNode
+			for _, child := range node.children {
+				if child.fileIndex < 0 && child.name == element {
+					dir = child
+					break
+				}
+			}
+			if dir == nil {
+				dir = &fileTreeNode{
+					name:      element,
+					path:      strings.Join(elements[:depth+1], "/"),
+					fileIndex: -1,
+				}
+				node.children = append(node.children, dir)
+			}
+			node = dir
+		}
+
+		node.children = append(node.children, &fileTreeNode{
+			name:      elements[len(elements)-1],
+			path:      strings.Join(elements, "/"),
+			fileIndex: i,
+		})
+	}
+
+	compressFileTree(root)
+	return root
+}
+
+// compressFileTree merges directories that only contain a single directory,
+// so deep paths like src/main/java take up one row
+func compressFileTree(node *fileTreeNode) {
+	for _, child := range node.children {
+		for child.fileIndex < 0 && len(child.children) == 1 && child.children[0].fileIndex < 0 {
+			only := child.children[0]
+			child.name += "/" + only.name
+			child.path = only.path
+			child.children = only.children
+		}
+		compressFileTree(child)
+	}
+}
+
+// fileTreeRows flattens the tree into list rows, skipping the contents of
+// collapsed directories
+func (m Model) fileTreeRows(node *fileTreeNode, depth int, rows []list.Item) ([]list.Item, lineStats) {
+	var total lineStats
+
+	for _, child := range node.children {
+		if child.fileIndex >= 0 {
+			stats := m.fileLineStats(child.fileIndex)
+			total = addLineStats(total, stats)
+			rows = append(rows, fileTreeItem{
+				depth:     depth,
+				name:      child.name,
+				path:      child.path,
+				fileIndex: child.fileIndex,
+				change:    m.prFiles[child.fileIndex],
+				stats:     stats,
+			})
+			continue
+		}
+
+		collapsed := m.collapsedDirs[child.path]
+		index := len(rows)
+		rows = append(rows, nil) // Filled in once the directory totals are known
+
+		var stats lineStats
+		if collapsed {
+			_, stats = m.fileTreeRows(child, depth+1, nil)
+		} else {
+			rows, stats = m.fileTreeRows(child, depth+1, rows)
+		}
+		total = addLineStats(total, stats)
+
+		rows[index] = fileTreeItem{
+			depth:     depth,
+			name:      child.name,
+			path:      child.path,
+			isDir:     true,
+			collapsed: collapsed,
+			fileIndex: -1,
+			stats:     stats,
+		}
+	}
+
+	return rows, total
+}
+
+// addLineStats sums the line counts of two sets of files
+func addLineStats(a, b lineStats) lineStats {
+	return lineStats{
+		added:   a.added + b.added,
+		deleted: a.deleted + b.deleted,
+		binary:  a.binary || b.binary,
+		pending: a.pending + b.pending,
+	}
+}
+
+// diffLineStats returns the line counts of a loaded diff
+func diffLineStats(diff azuredevops.FileDiff) lineStats {
+	if diff.IsBinary {
+		return lineStats{binary: true}
+	}
+
+	added, deleted := diff.Stats()
+	return lineStats{added: added, deleted: deleted}
+}
+
+// fileLineStats returns the line counts of a file, or no counts when they are
+// not being loaded
+func (m Model) fileLineStats(index int) lineStats {
+	return m.diffStats[m.prFiles[index].Item.Path]
+}
+
+// recordDiffStats stores the line counts of a loaded diff, rebuilding the
+// file tree only when they were not known yet
+func (m *Model) recordDiffStats(path string, diff azuredevops.FileDiff) {
+	stats := diffLineStats(diff)
+	if known, ok := m.diffStats[path]; ok && known == stats {
+		return
+	}
+	m.diffStats[path] = stats
+	m.updateFileList()
+}
+
+// fileVisible reports whether a file passes the configured exclusions and the
+// current file filter
+func (m Model) fileVisible(index int) bool {
+	path := m.prFiles[index].Item.Path
+
+	for _, pattern := range m.excludeFiles {
+		if pattern.Match(path) {
+			return false
+		}
+	}
+
+	if m.fileFilter != nil {
+		return m.fileFilter.Match(path) != m.fileFilterExclude
+	}
+	return true
+}
+
+// updateFileList updates the file tree with current PR files, keeping the
+// selected row when it is still shown
+func (m *Model) updateFileList() {
+	selected := ""
+	if item, ok := m.fileList.SelectedItem().(fileTreeItem); ok {
+		selected = item.path
+	}
+
+	rows, _ := m.fileTreeRows(m.buildFileTree(), 0, nil)
+	m.fileList.SetItems(rows)
+
+	for i, row := range rows {
+		if row.(fileTreeItem).path == selected {
+			m.fileList.Select(i)
+			break
+		}
+	}
+}
+
+// selectFileRow selects the tree row of the file at index in prFiles
+func (m *Model) selectFileRow(index int) {
+	for i, row := range m.fileList.Items() {
+		if row.(fileTreeItem).fileIndex == index {
+			m.fileList.Select(i)
+			return
+		}
+	}
+}
+
+// toggleFileDir collapses or expands a directory of the file tree
+func (m *Model) toggleFileDir(path string) {
+	if m.collapsedDirs[path] {
+		delete(m.collapsedDirs, path)
+	} else {
+		m.collapsedDirs[path] = true
+	}
+	m.updateFileList()
+}
+
+// neighbourFile returns the index of the next (direction 1) or previous
+// (direction -1) visible file in tree order, or -1 if there is none
+func (m Model) neighbourFile(index, direction int) int {
+	for i := index + direction; i >= 0 && i < len(m.prFiles); i += direction {
+		if m.fileVisible(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// startFileFilter opens the glob prompt of the files view
+func (m *Model) startFileFilter() tea.Cmd {
+	m.editingFileFilter = true
+	m.fileFilterInput.SetValue("")
+	if m.fileFilter != nil {
+		value := m.fileFilter.String()
+		if m.fileFilterExclude {
+			value = "!" + value
+		}
+		m.fileFilterInput.SetValue(value)
+	}
+	m.fileFilterInput.CursorEnd()
+	return m.fileFilterInput.Focus()
+}
+
+// updateFileFilter handles keys while the glob prompt is open. An empty glob
+// clears the filter and a leading '!' hides the matching files instead.
+func (m Model) updateFileFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		value := strings.TrimSpace(m.fileFilterInput.Value())
+		exclude := strings.HasPrefix(value, "!")
+		value = strings.TrimPrefix(value, "!")
+
+		if value == "" {
+			m.fileFilter = nil
+		} else {
+			pattern, err := glob.Compile(value)
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.fileFilter = pattern
+		}
+		m.err = nil
+		m.fileFilterExclude = exclude
+		m.editingFileFilter = false
+		m.fileFilterInput.Blur()
+		m.updateFileList()
+		return m, nil
+
+	case "esc":
+		m.editingFileFilter = false
+		m.fileFilterInput.Blur()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.fileFilterInput, cmd = m.fileFilterInput.Update(msg)
+	return m, cmd
+}
+
+// fileFilterLabel describes the active file filter for the status bar
+func (m Model) fileFilterLabel() string {
+	hidden := 0
+	for i := range m.prFiles {
+		if !m.fileVisible(i) {
+			hidden++
+		}
+	}
+
+	label := fmt.Sprintf("%d files", len(m.prFiles))
+	if m.fileFilter != nil {
+		verb := "matching"
+		if m.fileFilterExclude {
+			verb = "not matching"
+		}
+		label += fmt.Sprintf(" | Showing %s %s", verb, m.fileFilter)
+	}
+	if hidden > 0 {
+		label += fmt.Sprintf(" | %d hidden", hidden)
+	}
+	return label
+}
@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/azuredevops"
+)
+
+// followInterval is how often a followed build is polled
+const followInterval = 3 * time.Second
+
+// startFollowing follows the selected build: its active log is tailed and its
+// timeline kept up to date until the build completes
+func (m *Model) startFollowing() tea.Cmd {
+	if m.selectedBuild.Status == "completed" {
+		m.notice = "The build has completed"
+		return nil
+	}
+	m.following = true
+	m.followSeq++
+	m.followCtx, m.followCancel = context.WithCancel(m.ctx)
+	m.notice = "Following the build"
+	return m.pollFollowedBuild()
+}
+
+// stopFollowing stops following the selected build, canceling polls in flight
+func (m *Model) stopFollowing() {
+	if !m.following {
+		return
+	}
+	m.following = false
+	m.followPolling = false
+	m.followSeq++
+	m.followCancel()
+	m.notice = ""
+}
+
+// pollFollowedBuild returns a command that polls the followed build
+func (m *Model) pollFollowedBuild() tea.Cmd {
+	m.followPolling = true
+	m.lastFollowPoll = time.Now()
+
+	ctx, seq := m.followCtx, m.followSeq
+	project := m.selectedBuildProject
+	buildID := m.selectedBuild.ID
+	logID, lastLine := 0, 0
+	if len(m.buildLogList) > 0 {
+		logID, lastLine = m.buildLogList[m.logIndex].id, m.logLastLine
+	}
+
+	return func() tea.Msg {
+		return m.pollBuild(ctx, seq, project, buildID, logID, lastLine)
+	}
+}
+
+// pollBuild fetches the state of a followed build and the lines of its active
+// log that haven't been loaded yet. Only new lines of the followed log are
+// fetched; a newly started log is loaded from its end like any long log.
+func (m Model) pollBuild(ctx context.Context, seq int, project string, buildID, logID, lastLine int) FollowPolledMsg {
+	msg := FollowPolledMsg{seq: seq}
+
+	build, err := m.client.GetBuild(ctx, project, buildID)
+	if err != nil {
+		msg.err = err
+		return msg
+	}
+	msg.build = build
+
+	records, err := m.client.GetBuildTimeline(ctx, project, buildID)
+	if err != nil {
+		msg.err = err
+		return msg
+	}
+	msg.records = records
+
+	logs, err := m.client.GetBuildLogs(ctx, project, buildID)
+	if err != nil {
+		msg.err = err
+		return msg
+	}
+	msg.logs = namedLogs(logs, records)
+
+	msg.logID = activeLog(records, msg.logs)
+	if msg.logID == 0 {
+		return msg
+	}
+	if msg.logID != logID {
+		lastLine = 0
+	}
+
+	var lineCount int
+	for _, log := range msg.logs {
+		if log.id == msg.logID {
+			lineCount = log.lineCount
+		}
+	}
+	if lineCount <= lastLine {
+		return msg // Nothing new
+	}
+
+	msg.startLine = max(lastLine+1, lineCount-logChunkLines+1, 1)
+	msg.lines, msg.err = m.client.GetBuildLogLines(ctx, project, buildID, msg.logID, msg.startLine, lineCount)
+	return msg
+}
+
+// activeLog returns the log to follow: the log of the most recently started
+// task or job that is still running, or else the newest log
+func activeLog(records []azuredevops.TimelineRecord, logs []buildLog) int {
+	var active *azuredevops.TimelineRecord
+	for i, record := range records {
+		if record.Log == nil || record.State != azuredevops.RecordStateInProgress || record.StartTime == nil {
+			continue
+		}
+		if active == nil || record.StartTime.After(*active.StartTime) {
+			active = &records[i]
+		}
+	}
+	if active != nil {
+		return active.Log.ID
+	}
+
+	newest := 0
+	for _, log := range logs {
+		newest = max(newest, log.id)
+	}
+	return newest
+}
+
+// applyFollowPoll shows the result of polling a followed build and schedules
+// the next poll, or stops following once the build has completed
+func (m *Model) applyFollowPoll(msg FollowPolledMsg) {
+	m.followPolling = false
+	if msg.err != nil {
+		m.err = msg.err
+		return // Retried on the next tick
+	}
+	m.err = nil
+
+	build := msg.build
+	m.selectedBuild = &build
+	m.showTimeline(TimelineLoadedMsg{records: msg.records})
+
+	m.buildLogList = msg.logs
+	for i, log := range msg.logs {
+		if log.id == msg.logID {
+			switched := m.logIndex != i || len(m.buildLogs) == 0
+			m.logIndex = i
+			if switched {
+				m.buildLogs = ""
+				m.logFirstLine = max(msg.startLine, 1)
+				m.logLastLine = m.logFirstLine - 1
+				m.renderLogs() // Don't keep showing the previous log
+			}
+		}
+	}
+
+	if msg.lines != "" {
+		lines := strings.TrimRight(msg.lines, "\n")
+		if m.buildLogs == "" {
+			m.buildLogs = lines
+		} else {
+			m.buildLogs += "\n" + lines
+		}
+		m.logLastLine = msg.startLine + strings.Count(lines, "\n")
+		m.renderLogs()
+		m.logsViewport.GotoBottom()
+	}
+
+	if build.Status == "completed" {
+		m.stopFollowing()
+		m.notice = "The build has completed"
+		if build.Result != "" {
+			m.notice = fmt.Sprintf("The build has %s", build.Result)
+		}
+	}
+}
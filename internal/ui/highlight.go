@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// syntaxTheme is the bundled chroma style used for syntax highlighting
+const syntaxTheme = "monokai"
+
+// highlightToken is a run of source text with a single syntax style
+type highlightToken struct {
+	text  string
+	style lipgloss.Style
+}
+
+// fileHighlight holds the syntax highlighted lines of both sides of a diff,
+// indexed by line number minus one
+type fileHighlight struct {
+	oldLines [][]highlightToken
+	newLines [][]highlightToken
+}
+
+// highlightLines tokenizes a file with the lexer matching its path and splits
+// the tokens into lines. It returns nil when no lexer matches the file.
+func highlightLines(path, text string) [][]highlightToken {
+	if text == "" {
+		return nil
+	}
+
+	lexer := lexers.Match(path)
+	if lexer == nil {
+		return nil
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	iterator, err := lexer.Tokenise(nil, text)
+	if err != nil {
+		return nil
+	}
+
+	style := styles.Get(syntaxTheme)
+	lines := [][]highlightToken{nil}
+
+	for token := iterator(); token != chroma.EOF; token = iterator() {
+		tokenStyle := tokenLipglossStyle(style.Get(token.Type))
+
+		// Tokens may span lines, e.g. block comments and multi-line strings
+		parts := strings.Split(token.Value, "\n")
+		for i, part := range parts {
+			if i > 0 {
+				lines = append(lines, nil)
+			}
+			if part == "" {
+				continue
+			}
+			last := len(lines) - 1
+			lines[last] = append(lines[last], highlightToken{text: part, style: tokenStyle})
+		}
+	}
+
+	return lines
+}
+
+// tokenLipglossStyle converts a chroma style entry to a Lipgloss style
+func tokenLipglossStyle(entry chroma.StyleEntry) lipgloss.Style {
+	style := lipgloss.NewStyle()
+	if entry.Colour.IsSet() {
+		style = style.Foreground(lipgloss.Color(entry.Colour.String()))
+	}
+	if entry.Bold == chroma.Yes {
+		style = style.Bold(true)
+	}
+	if entry.Italic == chroma.Yes {
+		style = style.Italic(true)
+	}
+	return style
+}
+
+// renderCode renders a line of file content using its syntax highlighting when
+// available, with an optional background layered underneath
+func renderCode(lines [][]highlightToken, lineNumber int, text string, background lipgloss.TerminalColor) string {
+	if lineNumber < 1 || lineNumber > len(lines) || lines[lineNumber-1] == nil {
+		style := lipgloss.NewStyle()
+		if background != nil {
+			style = style.Background(background)
+		}
+		return style.Render(displayText(text))
+	}
+
+	var result strings.Builder
+	for _, token := range lines[lineNumber-1] {
+		style := token.style
+		if background != nil {
+			style = style.Copy().Background(background)
+		}
+		result.WriteString(style.Render(displayText(token.text)))
+	}
+	return result.String()
+}
@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/lipgloss"
@@ -11,7 +12,9 @@ import (
 
 // prItem wraps a PullRequest for use in a list
 type prItem struct {
-	pr azuredevops.PullRequest
+	pr     azuredevops.PullRequest
+	stale  bool   // The source failed to refresh and this is older data
+	userID string // Authenticated user, whose vote is shown
 }
 
 func (i prItem) FilterValue() string {
@@ -23,23 +26,37 @@ func (i prItem) Title() string {
 	if i.pr.IsDraft {
 		draftIndicator = "[DRAFT] "
 	}
-	return fmt.Sprintf("%sPR #%d: %s", draftIndicator, i.pr.ID, i.pr.Title)
+	checkIndicator := ""
+	if i.pr.Checks != nil && i.pr.Checks.State() != "" {
+		checkIndicator = getCheckIcon(i.pr.Checks.State()) + " "
+	}
+	return fmt.Sprintf("%s%s%sPR #%d: %s", checkIndicator, staleIndicator(i.stale), draftIndicator, i.pr.ID, i.pr.Title)
 }
 
 func (i prItem) Description() string {
 	branch := strings.TrimPrefix(i.pr.SourceRefName, "refs/heads/")
 	targetBranch := strings.TrimPrefix(i.pr.TargetRefName, "refs/heads/")
-	return fmt.Sprintf("%s/%s | %s → %s | by %s",
+	description := fmt.Sprintf("%s/%s | %s → %s | by %s",
 		i.pr.Repository.Project.Name,
 		i.pr.Repository.Name,
 		branch,
 		targetBranch,
 		i.pr.CreatedBy.DisplayName)
+
+	if votes := formatVoteSummary(i.pr.VoteSummary()); votes != "" {
+		description += " | " + votes
+	}
+	if reviewer, ok := i.pr.Reviewer(i.userID); ok && i.userID != "" && reviewer.Vote != azuredevops.VoteNone {
+		description += fmt.Sprintf(" | You: %s %s", getVoteIcon(reviewer.Vote), azuredevops.VoteLabel(reviewer.Vote))
+	}
+	return description
 }
 
 // buildItem wraps a Build for use in a list
 type buildItem struct {
-	build azuredevops.Build
+	build  azuredevops.Build
+	source int  // Index of the pipeline source the build was loaded from
+	stale  bool // The source failed to refresh and this is older data
 }
 
 func (i buildItem) FilterValue() string {
@@ -55,7 +72,7 @@ func (i buildItem) Title() string {
 	statusIcon := getStatusIcon(status)
 
 	// Show the actual build name from DevOps (which includes PR description, etc.)
-	return fmt.Sprintf("%s %s", statusIcon, i.build.BuildNumber)
+	return fmt.Sprintf("%s %s%s", statusIcon, staleIndicator(i.stale), i.build.BuildNumber)
 }
 
 func (i buildItem) Description() string {
@@ -82,21 +99,26 @@ func (i buildItem) Description() string {
 		i.build.RequestedFor.DisplayName)
 }
 
-// fileItem wraps a file path for use in a list
-type fileItem struct {
-	path string
-}
-
-func (i fileItem) FilterValue() string {
-	return i.path
-}
-
-func (i fileItem) Title() string {
-	return i.path
+// getChangeTypeBadge returns a colored one-letter badge for a file change type
+func getChangeTypeBadge(change azuredevops.PRChange) string {
+	switch {
+	case change.HasChangeType(azuredevops.ChangeTypeAdd):
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Bold(true).Render("[A]") // Green
+	case change.HasChangeType(azuredevops.ChangeTypeDelete):
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true).Render("[D]") // Red
+	case change.HasChangeType(azuredevops.ChangeTypeRename):
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Bold(true).Render("[R]") // Cyan
+	default:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true).Render("[M]") // Yellow
+	}
 }
 
-func (i fileItem) Description() string {
-	return ""
+// staleIndicator returns a marker for items whose source failed to refresh
+func staleIndicator(stale bool) string {
+	if !stale {
+		return ""
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render("[STALE]") + " "
 }
 
 // getStatusIcon returns a colored icon for a build status
@@ -155,28 +177,117 @@ func getColoredStatus(status string) string {
 	return style.Render(status)
 }
 
-// updateLists updates the list items with current data
+// applySource stores the data of a loaded source, updates its health and
+// rebuilds the combined pull request and build lists. Failed loads keep the
+// data from the last successful refresh.
+func (m *Model) applySource(msg SourceLoadedMsg) {
+	status := m.sourceStatuses[msg.source]
+	status.loaded = true
+
+	if msg.err != nil {
+		status.lastErr = msg.err
+		status.lastErrTime = time.Now()
+	} else {
+		status.lastErr = nil
+		status.partialErr = msg.partialErr
+		status.lastSuccess = time.Now()
+		if msg.source.kind == sourcePullRequests {
+			m.sourcePRs[msg.source.index] = keepPRStates(msg.pullRequests, m.sourcePRs[msg.source.index])
+		} else {
+			m.sourceBuilds[msg.source.index] = msg.builds
+		}
+	}
+
+	m.sourceStatuses[msg.source] = status
+	m.updateLists()
+}
+
+// keepPRStates copies the checks and vote state of previously loaded PRs to
+// the same PRs in a fresh list, so they stay shown until they are reloaded
+func keepPRStates(prs, previous []azuredevops.PullRequest) []azuredevops.PullRequest {
+	type prKey struct {
+		repository string
+		id         int
+	}
+	states := make(map[prKey]azuredevops.PullRequest, len(previous))
+	for _, pr := range previous {
+		states[prKey{pr.Repository.Name, pr.ID}] = pr
+	}
+
+	for i := range prs {
+		if old, ok := states[prKey{prs[i].Repository.Name, prs[i].ID}]; ok {
+			prs[i].Checks = old.Checks
+			prs[i].ChecksErr = old.ChecksErr
+			prs[i].VoteReset = old.VoteReset
+		}
+	}
+	return prs
+}
+
+// applyPRStates stores loaded checks and vote state on the PRs in every list
+// showing them and in the details view
+func (m *Model) applyPRStates(states []prState) {
+	apply := func(pr *azuredevops.PullRequest) bool {
+		for _, state := range states {
+			if state.prID == pr.ID && state.repositoryName == pr.Repository.Name {
+				pr.Checks = state.checks
+				pr.ChecksErr = state.checksErr
+				pr.VoteReset = state.voteReset
+				return true
+			}
+		}
+		return false
+	}
+
+	for index, prs := range m.sourcePRs {
+		updated := make([]azuredevops.PullRequest, len(prs))
+		copy(updated, prs)
+		for i := range updated {
+			apply(&updated[i])
+		}
+		m.sourcePRs[index] = updated
+	}
+	m.updateLists()
+
+	if m.selectedPR != nil {
+		selected := *m.selectedPR
+		if apply(&selected) {
+			m.selectedPR = &selected
+			m.refreshPRDetails()
+		}
+	}
+}
+
+// updateLists rebuilds the pull request and build lists from the data of
+// every source, in configuration order
 func (m *Model) updateLists() {
 	// Update PR list
-	prItems := make([]list.Item, len(m.pullRequests))
-	for i, pr := range m.pullRequests {
-		prItems[i] = prItem{pr: pr}
+	m.pullRequests = nil
+	var prItems []list.Item
+	for i := range m.config.PullRequests {
+		stale := m.sourceStatuses[sourceID{kind: sourcePullRequests, index: i}].stale()
+		for _, pr := range m.sourcePRs[i] {
+			if !m.prView.includes(pr, m.currentUser.ID) {
+				continue
+			}
+			m.pullRequests = append(m.pullRequests, pr)
+			prItems = append(prItems, prItem{pr: pr, stale: stale, userID: m.currentUser.ID})
+		}
 	}
 	m.prList.SetItems(prItems)
+	m.prList.Title = m.prListTitle()
 
 	// Update build list
-	buildItems := make([]list.Item, len(m.builds))
-	for i, build := range m.builds {
-		buildItems[i] = buildItem{build: build}
+	m.builds = nil
+	var buildItems []list.Item
+	for i := range m.config.Pipelines {
+		stale := m.sourceStatuses[sourceID{kind: sourcePipeline, index: i}].stale()
+		for _, build := range m.sourceBuilds[i] {
+			m.builds = append(m.builds, build)
+			buildItems = append(buildItems, buildItem{build: build, source: i, stale: stale})
+		}
 	}
 	m.buildList.SetItems(buildItems)
-}
 
-// updateFileList updates the file list with current PR files
-func (m *Model) updateFileList() {
-	fileItems := make([]list.Item, len(m.prFiles))
-	for i, file := range m.prFiles {
-		fileItems[i] = fileItem{path: file}
-	}
-	m.fileList.SetItems(fileItems)
+	m.updateSourceList()
 }
@@ -0,0 +1,205 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/azuredevops"
+)
+
+// logChunkLines is the number of lines of a log loaded at a time. Longer logs
+// are loaded from the end, where failures are, and earlier lines on request.
+const logChunkLines = 2000
+
+// buildLog is a log of the selected build, named after its timeline record
+type buildLog struct {
+	id        int
+	name      string
+	lineCount int
+	failed    bool // The stage, job or task that wrote the log failed
+}
+
+// openBuildLogs shows the log viewer for the selected build, starting at the
+// log with the given ID, or at the first failed log when logID is 0
+func (m *Model) openBuildLogs(logID int, returnView View) tea.Cmd {
+	m.notice = ""
+	m.logsReturnView = returnView
+	m.buildLogList = nil
+	m.loadingLogs = true
+	m.view = ViewBuildLogs
+	ctx, seq := m.beginViewRequest()
+	return m.loadLogList(ctx, seq, m.selectedBuildProject, m.selectedBuild.ID, logID)
+}
+
+// loadLogList loads the list of logs of a build and names them after the
+// timeline records that wrote them
+func (m Model) loadLogList(ctx context.Context, seq int, project string, buildID, logID int) tea.Cmd {
+	return func() tea.Msg {
+		logs, err := m.client.GetBuildLogs(ctx, project, buildID)
+		if err != nil {
+			return LogListLoadedMsg{seq: seq, err: fmt.Errorf("failed to load build logs: %w", err)}
+		}
+
+		// Names are a nicety, so logs are still shown without a timeline
+		records, _ := m.client.GetBuildTimeline(ctx, project, buildID)
+		return LogListLoadedMsg{seq: seq, logs: namedLogs(logs, records), logID: logID}
+	}
+}
+
+// namedLogs names the logs of a build after the timeline records that wrote them
+func namedLogs(logs []azuredevops.BuildLog, records []azuredevops.TimelineRecord) []buildLog {
+	byLog := make(map[int]azuredevops.TimelineRecord)
+	for _, record := range records {
+		if record.Log != nil {
+			byLog[record.Log.ID] = record
+		}
+	}
+
+	list := make([]buildLog, len(logs))
+	for i, log := range logs {
+		list[i] = buildLog{id: log.ID, name: fmt.Sprintf("Log %d", log.ID), lineCount: log.LineCount}
+		if record, ok := byLog[log.ID]; ok {
+			list[i].name = record.Name
+			list[i].failed = record.Result == "failed"
+		}
+	}
+	return list
+}
+
+// showLogList selects the log to show first from a loaded log list and loads it
+func (m *Model) showLogList(msg LogListLoadedMsg) tea.Cmd {
+	if msg.err != nil {
+		m.loadingLogs = false
+		m.err = msg.err
+		return nil
+	}
+	m.buildLogList = msg.logs
+	if len(msg.logs) == 0 {
+		m.loadingLogs = false
+		m.logFirstLine = 0
+		m.logsViewport.SetContent("No logs available for this build")
+		return nil
+	}
+
+	m.logIndex = 0
+	for i, log := range msg.logs {
+		if msg.logID != 0 && log.id == msg.logID {
+			m.logIndex = i
+			break
+		}
+		if msg.logID == 0 && log.failed {
+			m.logIndex = i
+			break
+		}
+	}
+	return m.loadLog(m.logIndex)
+}
+
+// loadLog shows a log of the list, loading the end of long logs
+func (m *Model) loadLog(index int) tea.Cmd {
+	m.logIndex = index
+	m.logFirstLine = 0
+	m.loadingLogs = true
+	m.err = nil
+	log := m.buildLogList[index]
+
+	startLine, endLine := 0, 0 // The whole log
+	if log.lineCount > logChunkLines {
+		startLine, endLine = log.lineCount-logChunkLines+1, log.lineCount
+	}
+	ctx, seq := m.beginViewRequest()
+	return m.fetchLogLines(ctx, seq, log.id, startLine, endLine)
+}
+
+// loadEarlierLines loads the chunk of the current log before the lines shown
+func (m *Model) loadEarlierLines() tea.Cmd {
+	if m.loadingLogs || m.logFirstLine <= 1 {
+		return nil
+	}
+	startLine := max(1, m.logFirstLine-logChunkLines)
+	m.loadingLogs = true
+	ctx, seq := m.beginViewRequest()
+	return m.fetchLogLines(ctx, seq, m.buildLogList[m.logIndex].id, startLine, m.logFirstLine-1)
+}
+
+// fetchLogLines fetches lines of a log of the selected build. A startLine of
+// 0 fetches the whole log.
+func (m Model) fetchLogLines(ctx context.Context, seq int, logID, startLine, endLine int) tea.Cmd {
+	project := m.selectedBuildProject
+	buildID := m.selectedBuild.ID
+	return func() tea.Msg {
+		var content string
+		var err error
+		if startLine == 0 {
+			content, err = m.client.GetBuildLogContent(ctx, project, buildID, logID)
+		} else {
+			content, err = m.client.GetBuildLogLines(ctx, project, buildID, logID, startLine, endLine)
+		}
+		if err != nil {
+			return LogsLoadedMsg{seq: seq, err: fmt.Errorf("failed to load log: %w", err)}
+		}
+		return LogsLoadedMsg{seq: seq, logID: logID, startLine: startLine, logs: content}
+	}
+}
+
+// showLogLines shows loaded log lines, in front of the lines already shown
+// when they come before them
+func (m *Model) showLogLines(msg LogsLoadedMsg) {
+	m.loadingLogs = false
+	if msg.err != nil {
+		m.err = msg.err
+		return
+	}
+	if len(m.buildLogList) == 0 || msg.logID != m.buildLogList[m.logIndex].id {
+		return
+	}
+
+	content := strings.TrimRight(msg.logs, "\n")
+	firstLine := max(msg.startLine, 1)
+	if m.logFirstLine > 1 && firstLine < m.logFirstLine {
+		added := strings.Count(content, "\n") + 1
+		m.buildLogs = content + "\n" + m.buildLogs
+		m.logFirstLine = firstLine
+		m.renderLogs()
+		m.logsViewport.SetYOffset(m.logsViewport.YOffset + added)
+		return
+	}
+
+	m.buildLogs = content
+	m.logFirstLine = firstLine
+	m.logLastLine = firstLine + strings.Count(content, "\n")
+	if content == "" {
+		m.logLastLine = firstLine - 1
+	}
+	m.renderLogs()
+	if msg.startLine > 1 {
+		m.logsViewport.GotoBottom() // The end of a long log
+	} else {
+		m.logsViewport.GotoTop()
+	}
+}
+
+// moveLog shows the next or previous log of the build
+func (m *Model) moveLog(direction int) tea.Cmd {
+	index := m.logIndex + direction
+	if index < 0 || index >= len(m.buildLogList) {
+		return nil
+	}
+	m.stopFollowing() // Following would jump straight back to the active log
+	return m.loadLog(index)
+}
+
+// logPosition describes the log shown and which of its lines are loaded
+func (m Model) logPosition() string {
+	if len(m.buildLogList) == 0 {
+		return ""
+	}
+	log := m.buildLogList[m.logIndex]
+	position := fmt.Sprintf("Log %d/%d: %s", m.logIndex+1, len(m.buildLogList), log.name)
+	if m.logFirstLine > 1 {
+		position += fmt.Sprintf(" (lines %d-%d of %d, 'u' loads earlier lines)", m.logFirstLine, log.lineCount, log.lineCount)
+	}
+	return position
+}
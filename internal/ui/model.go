@@ -1,18 +1,24 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"runtime"
 	"strings"
 	"time"
 
+	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/ulve/azuredevops-terminal-dashboard/internal/azuredevops"
 	"github.com/ulve/azuredevops-terminal-dashboard/internal/config"
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/glob"
 )
 
 // View represents different views in the application
@@ -24,64 +30,244 @@ const (
 	ViewPRFiles
 	ViewFileDiff
 	ViewBuildLogs
+	ViewBuildDetails
 )
 
 // Model represents the application state
 type Model struct {
-	config          *config.Config
-	client          *azuredevops.Client
-	view            View
-	pullRequests    []azuredevops.PullRequest
-	builds          []azuredevops.Build
-	prList          list.Model
-	buildList       list.Model
-	fileList        list.Model
-	diffViewport    viewport.Model
-	logsViewport    viewport.Model
-	prDetailsViewport viewport.Model
-	selectedPR      *azuredevops.PullRequest
-	selectedBuild   *azuredevops.Build
-	selectedBuildProject string
-	prFiles         []string
-	currentDiff     string
-	buildLogs       string
-	loading         bool
-	loadingLogs     bool
-	err             error
-	lastUpdate      time.Time
-	autoRefresh     bool
-	refreshInterval time.Duration
-	width           int
-	height          int
-	activeTab       int // 0 = PRs, 1 = Builds
+	config               *config.Config
+	client               *azuredevops.Client
+	view                 View
+	pullRequests         []azuredevops.PullRequest
+	builds               []azuredevops.Build
+	sourcePRs            map[int][]azuredevops.PullRequest // Pull requests by pull request source index
+	sourceBuilds         map[int][]azuredevops.Build       // Builds by pipeline source index
+	sourceStatuses       map[sourceID]sourceStatus         // Health of each configured source
+	prList               list.Model
+	buildList            list.Model
+	fileList             list.Model
+	sourceList           list.Model
+	diffViewport         viewport.Model
+	logsViewport         viewport.Model
+	prDetailsViewport    viewport.Model
+	selectedPR           *azuredevops.PullRequest
+	prThreads            []azuredevops.PRThread // Comment threads of the selected PR, nil while loading
+	threadsErr           error
+	threadsSeq           int   // Sequence number of the latest threads request
+	selectedThread       int   // Highlighted thread in the details view, an index into discussionThreads
+	detailsThreadOffset  int   // Line of the details viewport where the comments section starts
+	detailsThreadOffsets []int // Line of each thread within the comments section
+	composing            bool  // The comment editor is open
+	composeInput         textarea.Model
+	composeTitle         string
+	composeThreadID      int                        // Thread being replied to, 0 for a new thread
+	composeContext       *azuredevops.ThreadContext // File position of a new thread, nil for a general comment
+	prompt               *prompt                    // Question shown in place of the status bar, nil when none
+	currentUser          azuredevops.Identity       // User the PAT belongs to, empty until looked up
+	completion           *completionDialog          // Open PR completion dialog, nil when closed
+	prView               prView                     // Which PRs the dashboard shows
+	selectedBuild        *azuredevops.Build
+	selectedBuildProject string // Project of the selected build
+	timelineList         list.Model
+	loadingTimeline      bool
+	buildLogList         []buildLog // Logs of the selected build, nil until loaded
+	logIndex             int        // Log shown, an index into buildLogList
+	logFirstLine         int        // First loaded line of the log shown, greater than 1 when only its end is loaded
+	logsReturnView       View       // View to go back to from the build logs
+	logLastLine          int        // Last loaded line of the log shown
+	following            bool       // The selected build is being followed
+	followPolling        bool       // A poll of the followed build is in flight
+	followSeq            int        // Sequence number of the current follow session
+	followCtx            context.Context
+	followCancel         context.CancelFunc // Cancels the polls of the current follow session
+	lastFollowPoll       time.Time
+	prFiles              []azuredevops.PRChange
+	prCommits            azuredevops.PRCommits    // Commits the selected PR's file diffs are computed between
+	prIteration          int                      // Iteration of the selected PR the files were loaded at
+	diffCache            map[string]DiffLoadedMsg // Loaded diffs of the selected PR by file path
+	diffStats            map[string]lineStats     // Line counts of the selected PR's files by path
+	diffClaims           *diffClaims              // Paths being prefetched in the background
+	collapsedDirs        map[string]bool          // Collapsed directories of the file tree
+	excludeFiles         []*glob.Pattern          // Configured globs of files hidden from the tree
+	fileFilter           *glob.Pattern            // Glob entered in the files view, nil when unset
+	fileFilterExclude    bool                     // The file filter hides matches instead of showing only them
+	fileFilterInput      textinput.Model
+	editingFileFilter    bool
+	searchInput          textinput.Model
+	searching            bool       // The search prompt of the logs or diff view is open
+	searchPrevious       string     // Query to put back when the search prompt is cancelled
+	searchOrigin         int        // Log line or diff row the search started from
+	logSearch            textSearch // Search through the loaded log lines
+	diffSearch           textSearch // Search through the rows of the diff
+	diffIndex            int        // Index in prFiles of the diff being shown
+	currentDiff          azuredevops.FileDiff
+	diffHighlight        fileHighlight
+	diffRows             []diffRow // Rendered rows of the diff viewport
+	diffCursor           int       // Selected row of the diff viewport
+	diffHunkOffsets      []int     // Row of each hunk in the diff viewport
+	diffMode             DiffMode
+	buildLogs            string
+	loading              bool
+	loadingLogs          bool
+	err                  error
+	notice               string // Short confirmation shown in the status bar of the current view
+	lastUpdate           time.Time
+	autoRefresh          bool
+	refreshInterval      time.Duration
+	width                int
+	height               int
+	activeTab            int                // 0 = PRs, 1 = Builds, 2 = Sources
+	ctx                  context.Context    // Root context of every request, canceled on quit
+	cancel               context.CancelFunc // Cancels the root context
+	refreshCtx           context.Context    // Context of the data refresh in flight
+	refreshCancel        context.CancelFunc // Cancels the data refresh in flight
+	refreshSeq           int                // Sequence number of the latest data refresh
+	prStateSlots         chan struct{}      // Bounds the PR state requests of the refresh in flight
+	viewCancel           context.CancelFunc // Cancels the files/diff/logs request in flight
+	viewSeq              int                // Sequence number of the latest view request
+	prefetchCtx          context.Context    // Context of the diff prefetches in flight
+	prefetchCancel       context.CancelFunc // Cancels the diff prefetches in flight
+	prefetchSeq          int                // Sequence number of the current prefetch generation
 }
 
 // TickMsg represents a timer tick for auto-refresh
 type TickMsg time.Time
 
-// DataLoadedMsg represents loaded data
-type DataLoadedMsg struct {
+// SourceLoadedMsg represents data loaded from a single configured source
+type SourceLoadedMsg struct {
+	seq          int
+	source       sourceID
 	pullRequests []azuredevops.PullRequest
 	builds       []azuredevops.Build
 	err          error
+	partialErr   error                  // Part of the source failed, the rest was loaded
+	results      <-chan SourceLoadedMsg // Remaining results of the same refresh
+}
+
+// PRStatesLoadedMsg represents the loaded checks and vote state of a source's PRs
+type PRStatesLoadedMsg struct {
+	seq    int
+	states []prState
+}
+
+// RefreshDoneMsg signals that every source of a refresh has been loaded
+type RefreshDoneMsg struct {
+	seq int
 }
 
 // FilesLoadedMsg represents loaded PR files
 type FilesLoadedMsg struct {
-	files []string
-	err   error
+	seq       int
+	files     []azuredevops.PRChange
+	commits   azuredevops.PRCommits
+	iteration int // Iteration the files were compared at
+	err       error
 }
 
 // DiffLoadedMsg represents loaded file diff
 type DiffLoadedMsg struct {
-	diff string
-	err  error
+	seq       int
+	index     int // Index of the file in prFiles
+	diff      azuredevops.FileDiff
+	highlight fileHighlight
+	err       error
+}
+
+// DiffPrefetchedMsg represents a file diff loaded ahead of being viewed
+type DiffPrefetchedMsg struct {
+	seq  int
+	path string
+	diff DiffLoadedMsg
+}
+
+// DiffStatsLoadedMsg represents a batch of line counts of the selected PR's files
+type DiffStatsLoadedMsg struct {
+	seq     int
+	stats   map[string]lineStats // Line counts by path
+	results <-chan fileLineStats // Remaining counts of the same load
+}
+
+// ThreadsLoadedMsg represents the loaded comment threads of the selected PR
+type ThreadsLoadedMsg struct {
+	seq     int
+	threads []azuredevops.PRThread
+	err     error
+}
+
+// ThreadChangedMsg represents a posted comment or a changed thread status
+type ThreadChangedMsg struct {
+	prID     int
+	threadID int
+	thread   *azuredevops.PRThread  // Newly created thread
+	comment  *azuredevops.PRComment // Reply added to threadID
+	status   string                 // New status of threadID
+	err      error
+}
+
+// IdentityLoadedMsg represents the looked up authenticated user
+type IdentityLoadedMsg struct {
+	identity azuredevops.Identity
+	err      error
+}
+
+// VoteCastMsg represents a vote cast on a pull request
+type VoteCastMsg struct {
+	prID           int
+	repositoryName string
+	reviewer       azuredevops.Reviewer
+	err            error
 }
 
-// LogsLoadedMsg represents loaded build logs
+// PoliciesLoadedMsg represents the loaded policy evaluations of a pull request
+type PoliciesLoadedMsg struct {
+	prID     int
+	policies []azuredevops.PolicyEvaluation
+	err      error
+}
+
+// PRUpdatedMsg represents a pull request changed from the dashboard
+type PRUpdatedMsg struct {
+	prID           int
+	repositoryName string
+	pr             azuredevops.PullRequest
+	notice         string
+	err            error
+}
+
+// TimelineLoadedMsg represents the loaded timeline of the selected build
+type TimelineLoadedMsg struct {
+	seq     int
+	records []azuredevops.TimelineRecord
+	err     error
+}
+
+// FollowPolledMsg represents the polled state of a followed build
+type FollowPolledMsg struct {
+	seq       int
+	build     azuredevops.Build
+	records   []azuredevops.TimelineRecord
+	logs      []buildLog
+	logID     int    // Log being followed, 0 when the build has no logs yet
+	startLine int    // First line of lines
+	lines     string // New lines of the followed log
+	err       error
+}
+
+// LogListLoadedMsg represents the loaded list of logs of the selected build
+type LogListLoadedMsg struct {
+	seq   int
+	logs  []buildLog
+	logID int // Log to show first, 0 for the first failed one
+	err   error
+}
+
+// LogsLoadedMsg represents loaded lines of a build log
 type LogsLoadedMsg struct {
-	logs string
-	err  error
+	seq       int
+	logID     int
+	startLine int // First line loaded, 0 when the whole log was loaded
+	logs      string
+	err       error
 }
 
 // NewModel creates a new application model
@@ -102,11 +288,51 @@ func NewModel(cfg *config.Config, client *azuredevops.Client) Model {
 
 	// Create file list
 	fileDelegate := list.NewDefaultDelegate()
+	fileDelegate.ShowDescription = false
+	fileDelegate.SetSpacing(0)
 	fileList := list.New([]list.Item{}, fileDelegate, 0, 0)
 	fileList.Title = "Changed Files"
 	fileList.SetShowStatusBar(false)
 	fileList.SetFilteringEnabled(false)
 
+	// Create build timeline list
+	timelineDelegate := list.NewDefaultDelegate()
+	timelineDelegate.ShowDescription = false
+	timelineDelegate.SetSpacing(0)
+	timelineList := list.New([]list.Item{}, timelineDelegate, 0, 0)
+	timelineList.Title = "Stages, jobs and tasks"
+	timelineList.SetShowStatusBar(false)
+	timelineList.SetFilteringEnabled(false)
+
+	// Create source list
+	sourceDelegate := list.NewDefaultDelegate()
+	sourceList := list.New([]list.Item{}, sourceDelegate, 0, 0)
+	sourceList.Title = "Sources"
+	sourceList.SetShowStatusBar(false)
+	sourceList.SetFilteringEnabled(false)
+
+	// Create the glob prompt of the files view
+	fileFilterInput := textinput.New()
+	fileFilterInput.Prompt = "Filter files (glob, !glob to hide): "
+
+	// Create the search prompt of the logs and diff views
+	searchInput := textinput.New()
+	searchInput.Prompt = "Search (regex): "
+
+	// Files hidden by configuration, already checked by config validation
+	var excludeFiles []*glob.Pattern
+	for _, pattern := range cfg.ExcludeFiles {
+		if compiled, err := glob.Compile(pattern); err == nil {
+			excludeFiles = append(excludeFiles, compiled)
+		}
+	}
+
+	// Create the comment editor
+	composeInput := textarea.New()
+	composeInput.Placeholder = "Write a comment..."
+	composeInput.ShowLineNumbers = false
+	composeInput.SetHeight(4)
+
 	// Create diff viewport
 	diffViewport := viewport.New(0, 0)
 
@@ -116,27 +342,50 @@ func NewModel(cfg *config.Config, client *azuredevops.Client) Model {
 	// Create PR details viewport
 	prDetailsViewport := viewport.New(0, 0)
 
-	return Model{
-		config:          cfg,
-		client:          client,
-		view:            ViewDashboard,
-		prList:          prList,
-		buildList:       buildList,
-		fileList:        fileList,
-		diffViewport:    diffViewport,
-		logsViewport:    logsViewport,
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m := Model{
+		config:            cfg,
+		client:            client,
+		view:              ViewDashboard,
+		prList:            prList,
+		buildList:         buildList,
+		fileList:          fileList,
+		sourceList:        sourceList,
+		timelineList:      timelineList,
+		fileFilterInput:   fileFilterInput,
+		searchInput:       searchInput,
+		composeInput:      composeInput,
+		excludeFiles:      excludeFiles,
+		collapsedDirs:     make(map[string]bool),
+		diffViewport:      diffViewport,
+		logsViewport:      logsViewport,
 		prDetailsViewport: prDetailsViewport,
-		loading:         true,
-		autoRefresh:     true,
-		refreshInterval: time.Duration(cfg.RefreshInterval) * time.Second,
-		activeTab:       0,
+		sourcePRs:         make(map[int][]azuredevops.PullRequest),
+		sourceBuilds:      make(map[int][]azuredevops.Build),
+		sourceStatuses:    make(map[sourceID]sourceStatus),
+		loading:           true,
+		autoRefresh:       true,
+		refreshInterval:   time.Duration(cfg.RefreshInterval) * time.Second,
+		activeTab:         0,
+		ctx:               ctx,
+		cancel:            cancel,
 	}
+
+	// List every configured source before the first refresh completes
+	m.updateSourceList()
+
+	// Start the first refresh like any other so that r can supersede it
+	m.beginRefresh()
+
+	return m
 }
 
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
-		m.loadData(),
+		m.loadData(m.refreshCtx, m.refreshSeq),
+		m.loadIdentity(),
 		m.tickCmd(),
 	)
 }
@@ -150,46 +399,81 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.updateSizes()
+		m.refreshDiffView()
+		m.refreshPRDetails()
 
 	case tea.KeyMsg:
+		// Quitting always works, even while typing or answering a prompt
+		if msg.String() == "ctrl+c" {
+			return m, m.quit()
+		}
+		if m.editingFileFilter {
+			return m.updateFileFilter(msg)
+		}
+		if m.searching {
+			return m.updateSearch(msg)
+		}
+		if m.composing {
+			return m.updateCompose(msg)
+		}
+		if m.prompt != nil {
+			return m.updatePrompt(msg)
+		}
+		if m.completion != nil && m.view == ViewPRDetails {
+			return m.updateCompletion(msg)
+		}
+
 		switch msg.String() {
 		case "ctrl+c", "q":
-			return m, tea.Quit
+			return m, m.quit()
 
 		case "r":
-			// Manual refresh
+			// Manual refresh, superseding any refresh in flight
 			m.loading = true
-			return m, m.loadData()
+			ctx, seq := m.beginRefresh()
+			return m, m.loadData(ctx, seq)
 
 		case "tab":
 			// Switch between tabs in dashboard view
 			if m.view == ViewDashboard {
-				m.activeTab = (m.activeTab + 1) % 2
+				m.activeTab = (m.activeTab + 1) % 3
 			}
 
 		case "enter":
 			return m.handleEnter()
 
 		case "h", "left":
-			// Go back to previous view
+			// Go back to previous view, abandoning anything still loading for it
+			m.cancelViewRequest()
+			m.stopFollowing()
+			m.loadingLogs = false
+			m.notice = ""
 			switch m.view {
 			case ViewPRDetails:
 				m.view = ViewDashboard
 				m.err = nil // Clear errors when going back
 			case ViewPRFiles:
+				m.cancelPrefetch()
 				m.view = ViewPRDetails
 				m.err = nil // Clear errors when going back
+				cmds = append(cmds, m.reloadPRThreads())
 			case ViewFileDiff:
 				m.view = ViewPRFiles
 				m.err = nil // Clear errors when going back
 			case ViewBuildLogs:
+				m.view = m.logsReturnView
+				m.err = nil
+				if m.view == ViewPRDetails {
+					cmds = append(cmds, m.reloadPRThreads())
+				}
+			case ViewBuildDetails:
 				m.view = ViewDashboard
 				m.err = nil // Clear errors when going back
 			}
 
 		case "g":
 			// Open build in browser when in build logs view
-			if m.view == ViewBuildLogs && m.selectedBuild != nil {
+			if (m.view == ViewBuildLogs || m.view == ViewBuildDetails) && m.selectedBuild != nil {
 				return m, m.openBuildURL()
 			}
 			// Open PR in browser when in PR details view
@@ -197,82 +481,422 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, m.openPRURL()
 			}
 
+		case "s":
+			// Toggle between unified and side-by-side diff
+			if m.view == ViewFileDiff {
+				if m.diffMode == DiffModeSideBySide {
+					m.diffMode = DiffModeUnified
+				} else {
+					m.diffMode = DiffModeSideBySide
+				}
+				m.refreshDiffView()
+			}
+
+		case "f":
+			// Switch between all PRs, my PRs and PRs waiting for my review
+			if m.view == ViewDashboard && m.activeTab == 0 {
+				m.cyclePRView()
+				return m, nil // f also pages the list
+			}
+			// Toggle between the diff and the full new version of the file
+			if m.view == ViewFileDiff {
+				if m.diffMode == DiffModeFullFile {
+					m.diffMode = DiffModeUnified
+				} else {
+					m.diffMode = DiffModeFullFile
+				}
+				m.diffCursor = 0
+				m.refreshDiffView()
+				m.diffViewport.GotoTop()
+				return m, nil // f also pages down in the viewport
+			}
+
+		case "]", "[":
+			// Select the next or previous comment thread
+			if m.view == ViewPRDetails {
+				direction := 1
+				if msg.String() == "[" {
+					direction = -1
+				}
+				m.moveSelectedThread(direction)
+			}
+			// Show the next or previous log of the build
+			if m.view == ViewBuildLogs {
+				direction := 1
+				if msg.String() == "[" {
+					direction = -1
+				}
+				return m, m.moveLog(direction)
+			}
+			// Move to the next or previous file of the PR
+			if m.view == ViewFileDiff {
+				direction := 1
+				if msg.String() == "[" {
+					direction = -1
+				}
+				if index := m.neighbourFile(m.diffIndex, direction); index >= 0 {
+					return m, m.openFileDiff(index)
+				}
+			}
+
+		case "/":
+			// Filter the changed files by glob
+			if m.view == ViewPRFiles {
+				return m, m.startFileFilter()
+			}
+			// Search the log or diff
+			if m.view == ViewBuildLogs || m.view == ViewFileDiff {
+				return m, m.startSearch()
+			}
+
+		case "n":
+			// Show the next match of the search
+			m.stepSearch(1)
+
+		case "N":
+			// Show the previous match of the search
+			m.stepSearch(-1)
+
+		case "e":
+			// Jump to the first error of the log
+			if m.view == ViewBuildLogs {
+				m.jumpToFirstError()
+			}
+
+		case "}":
+			// Jump to the next hunk
+			if m.view == ViewFileDiff {
+				m.scrollToHunk(1)
+			}
+
+		case "{":
+			// Jump to the previous hunk
+			if m.view == ViewFileDiff {
+				m.scrollToHunk(-1)
+			}
+
+		case "y":
+			// Copy the diff as a patch that applies with git apply
+			if m.view == ViewFileDiff {
+				if err := clipboard.WriteAll(m.currentDiff.Unified()); err != nil {
+					m.err = fmt.Errorf("failed to copy patch: %w", err)
+				} else {
+					m.notice = "Patch copied to clipboard"
+				}
+			}
+
+		case "b":
+			// Show the logs of the build validating the PR
+			if m.view == ViewPRDetails && m.selectedPR != nil {
+				return m, m.openValidatingBuild()
+			}
+
+		case "l":
+			// Browse the logs of the build, starting at the first failed one
+			if m.view == ViewBuildDetails && m.selectedBuild != nil {
+				return m, m.openBuildLogs(0, ViewBuildDetails)
+			}
+
+		case "F":
+			// Follow the active log of a running build
+			if m.view == ViewBuildLogs && m.selectedBuild != nil && len(m.buildLogList) > 0 {
+				if m.following {
+					m.stopFollowing()
+				} else {
+					return m, m.startFollowing()
+				}
+			}
+
+		case "u":
+			// Load the lines before the end of a long log
+			if m.view == ViewBuildLogs {
+				return m, m.loadEarlierLines()
+			}
+
 		case "c":
 			// Clone PR repository when in PR details view
 			if m.view == ViewPRDetails && m.selectedPR != nil {
 				return m, m.clonePRRepo()
 			}
+
+		case "C":
+			// Start a new thread on the PR, or on the selected line of the diff
+			switch m.view {
+			case ViewPRDetails:
+				return m, m.startCompose("New comment on the pull request", 0, nil)
+			case ViewFileDiff:
+				threadContext, err := m.diffCursorContext()
+				if err != nil {
+					m.notice = err.Error()
+					break
+				}
+				line := threadContext.RightFileStart
+				if line == nil {
+					line = threadContext.LeftFileStart
+				}
+				return m, m.startCompose(fmt.Sprintf("New comment on %s:%d", m.currentDiff.NewPath, line.Line), 0, threadContext)
+			}
+
+		case "v":
+			// Vote on the PR in details view
+			if m.view == ViewPRDetails && m.selectedPR != nil {
+				m.startVote()
+			}
+
+		case "m":
+			// Open the completion dialog in details view
+			if m.view == ViewPRDetails && m.selectedPR != nil {
+				if m.selectedPR.Status != "active" {
+					m.notice = fmt.Sprintf("PR #%d is %s", m.selectedPR.ID, m.selectedPR.Status)
+					break
+				}
+				return m, m.openCompletion()
+			}
+
+		case "X":
+			// Abandon the PR in details view
+			if m.view == ViewPRDetails && m.selectedPR != nil && m.selectedPR.Status == "active" {
+				m.confirmAbandon()
+			}
+
+		case "p":
+			// Publish a draft PR in details view
+			if m.view == ViewPRDetails && m.selectedPR != nil {
+				m.confirmPublish()
+			}
+
+		case "R":
+			// Reply to the selected thread
+			if m.view == ViewPRDetails || m.view == ViewFileDiff {
+				if threadID := m.selectedThreadID(); threadID != 0 {
+					return m, m.startCompose(fmt.Sprintf("Reply to thread #%d", threadID), threadID, nil)
+				}
+				m.notice = "Select a comment thread to reply to"
+			}
+
+		case "x":
+			// Resolve or reactivate the selected thread
+			if m.view == ViewPRDetails || m.view == ViewFileDiff {
+				if threadID := m.selectedThreadID(); threadID != 0 {
+					return m, m.toggleThreadStatus(threadID)
+				}
+				m.notice = "Select a comment thread to resolve"
+			}
 		}
 
 	case TickMsg:
-		if m.autoRefresh && time.Since(m.lastUpdate) >= m.refreshInterval {
-			cmds = append(cmds, m.loadData())
+		if m.autoRefresh && !m.loading && time.Since(m.lastUpdate) >= m.refreshInterval {
+			m.loading = true
+			ctx, seq := m.beginRefresh()
+			cmds = append(cmds, m.loadData(ctx, seq))
+		}
+		if m.following && !m.followPolling && time.Since(m.lastFollowPoll) >= followInterval {
+			cmds = append(cmds, m.pollFollowedBuild())
 		}
 		cmds = append(cmds, m.tickCmd())
 
-	case DataLoadedMsg:
+	case SourceLoadedMsg:
+		// Keep draining the refresh even when it has been superseded
+		cmds = append(cmds, waitForSource(msg.seq, msg.results))
+		if msg.seq != m.refreshSeq {
+			break // Superseded by a newer refresh
+		}
+		m.applySource(msg)
+		if msg.source.kind == sourcePullRequests && msg.err == nil {
+			// The list is shown first; checks and vote state follow
+			cmds = append(cmds, m.loadPRStates(msg.seq, msg.pullRequests))
+		}
+
+	case PRStatesLoadedMsg:
+		if msg.seq != m.refreshSeq {
+			break // Superseded by a newer refresh
+		}
+		m.applyPRStates(msg.states)
+
+	case RefreshDoneMsg:
+		if msg.seq != m.refreshSeq {
+			break // Superseded by a newer refresh
+		}
 		m.loading = false
 		m.lastUpdate = time.Now()
-		if msg.err != nil {
-			m.err = msg.err
-		} else {
-			m.pullRequests = msg.pullRequests
-			m.builds = msg.builds
-			m.updateLists()
-		}
 
 	case FilesLoadedMsg:
+		if msg.seq != m.viewSeq {
+			break // Canceled or superseded
+		}
 		if msg.err != nil {
 			m.err = msg.err
 			m.view = ViewPRFiles
 		} else {
 			m.err = nil // Clear any previous errors
 			m.prFiles = msg.files
+			sortFilesForTree(m.prFiles)
+			m.prCommits = msg.commits
+			m.prIteration = msg.iteration
+			m.cancelPrefetch()
+			m.diffCache = make(map[string]DiffLoadedMsg)
+			m.diffStats = make(map[string]lineStats)
+			m.collapsedDirs = make(map[string]bool)
+			m.fileFilter = nil
 			m.updateFileList()
+			m.fileList.Select(0)
 			m.view = ViewPRFiles
+			// Count the lines of each file in the background
+			return m, m.loadDiffStats()
 		}
 
 	case DiffLoadedMsg:
+		if msg.seq != m.viewSeq {
+			break // Canceled or superseded
+		}
 		if msg.err != nil {
 			m.err = msg.err
-			// Stay in ViewPRFiles so user can see error and try another file
+			// Stay in the current view so user can see error and try another file
 		} else {
-			m.err = nil // Clear any previous errors
-			m.currentDiff = msg.diff
-			// Format diff with colors
-			coloredDiff := m.formatDiff(msg.diff)
-			m.diffViewport.SetContent(coloredDiff)
-			m.view = ViewFileDiff
+			path := m.prFiles[msg.index].Item.Path
+			m.diffCache[path] = msg
+			m.recordDiffStats(path, msg.diff)
+			m.showFileDiff(msg)
+			return m, m.prefetchNeighbours(msg.index)
 		}
 
-	case LogsLoadedMsg:
-		m.loadingLogs = false
+	case DiffPrefetchedMsg:
+		if msg.seq != m.prefetchSeq {
+			break // Files reloaded or view left
+		}
+		if msg.diff.err != nil {
+			m.diffClaims.release(msg.path) // Let a later prefetch retry
+		} else {
+			m.diffCache[msg.path] = msg.diff
+			m.recordDiffStats(msg.path, msg.diff.diff)
+		}
+
+	case DiffStatsLoadedMsg:
+		// Keep draining the background load even when it has been superseded
+		cmds = append(cmds, waitForDiffStats(msg.seq, msg.results))
+		if msg.seq != m.prefetchSeq {
+			break // Files reloaded or view left
+		}
+		for path, stats := range msg.stats {
+			m.diffStats[path] = stats
+		}
+		m.updateFileList()
+
+	case IdentityLoadedMsg:
+		if msg.err == nil {
+			m.currentUser = msg.identity
+			m.updateLists()
+			m.refreshPRDetails()
+		}
+
+	case PoliciesLoadedMsg:
+		if m.completion == nil || m.selectedPR == nil || msg.prID != m.selectedPR.ID {
+			break // Dialog closed or another PR selected
+		}
+		dialog := *m.completion
+		dialog.loadingPolicies = false
+		dialog.policies = msg.policies
+		dialog.policiesErr = msg.err
+		m.completion = &dialog
+
+	case PRUpdatedMsg:
 		if msg.err != nil {
+			m.notice = ""
 			m.err = msg.err
-		} else {
-			m.buildLogs = msg.logs
-			m.logsViewport.SetContent(msg.logs)
-			m.view = ViewBuildLogs
+			break
 		}
+		m.err = nil
+		m.applyPRUpdate(msg)
+
+	case VoteCastMsg:
+		if msg.err != nil {
+			m.notice = ""
+			m.err = msg.err
+			break
+		}
+		m.err = nil
+		m.applyVote(msg)
+
+	case ThreadsLoadedMsg:
+		if msg.seq != m.threadsSeq {
+			break // Another PR was selected
+		}
+		if errors.Is(msg.err, context.Canceled) {
+			break // Left the view; reloaded when the details are shown again
+		}
+		m.threadsErr = msg.err
+		m.prThreads = msg.threads
+		if m.prThreads == nil {
+			m.prThreads = []azuredevops.PRThread{} // Loaded, but empty
+		}
+		m.refreshPRDetails()
+		if m.view == ViewFileDiff {
+			m.refreshDiffView()
+		}
+
+	case ThreadChangedMsg:
+		if m.selectedPR == nil || msg.prID != m.selectedPR.ID {
+			break // Another PR was selected
+		}
+		if msg.err != nil {
+			m.notice = ""
+			m.err = msg.err
+			break
+		}
+		m.err = nil
+		m.applyThreadChange(msg)
+
+	case TimelineLoadedMsg:
+		if msg.seq != m.viewSeq {
+			break // Canceled or superseded
+		}
+		m.showTimeline(msg)
+
+	case FollowPolledMsg:
+		if msg.seq != m.followSeq || !m.following {
+			break // Stopped following
+		}
+		m.applyFollowPoll(msg)
+
+	case LogListLoadedMsg:
+		if msg.seq != m.viewSeq {
+			break // Canceled or superseded
+		}
+		return m, m.showLogList(msg)
+
+	case LogsLoadedMsg:
+		if msg.seq != m.viewSeq {
+			break // Canceled or superseded
+		}
+		m.showLogLines(msg)
 	}
 
 	// Update active component based on view
 	var cmd tea.Cmd
 	switch m.view {
 	case ViewDashboard:
-		if m.activeTab == 0 {
+		switch m.activeTab {
+		case 0:
 			m.prList, cmd = m.prList.Update(msg)
-		} else {
+		case 1:
 			m.buildList, cmd = m.buildList.Update(msg)
+		case 2:
+			m.sourceList, cmd = m.sourceList.Update(msg)
 		}
 	case ViewPRDetails:
 		m.prDetailsViewport, cmd = m.prDetailsViewport.Update(msg)
 	case ViewPRFiles:
 		m.fileList, cmd = m.fileList.Update(msg)
 	case ViewFileDiff:
+		if keyMsg, ok := msg.(tea.KeyMsg); ok && m.handleDiffCursorKey(keyMsg) {
+			break
+		}
 		m.diffViewport, cmd = m.diffViewport.Update(msg)
+		m.followDiffViewport()
 	case ViewBuildLogs:
 		m.logsViewport, cmd = m.logsViewport.Update(msg)
+	case ViewBuildDetails:
+		m.timelineList, cmd = m.timelineList.Update(msg)
 	}
 	cmds = append(cmds, cmd)
 
@@ -296,6 +920,8 @@ func (m Model) View() string {
 		return m.renderFileDiff()
 	case ViewBuildLogs:
 		return m.renderBuildLogs()
+	case ViewBuildDetails:
+		return m.renderBuildDetails()
 	}
 
 	return ""
@@ -339,35 +965,59 @@ func (m Model) renderDashboard() string {
 	// Tabs
 	prTab := tabStyle.Render(fmt.Sprintf("Pull Requests (%d)", len(m.pullRequests)))
 	buildTab := tabStyle.Render(fmt.Sprintf("Builds (%d)", len(m.builds)))
+	sourcesLabel := fmt.Sprintf("Sources (%d)", len(m.sourceIDs()))
+	if failing := m.failingSources(); failing > 0 {
+		sourcesLabel = fmt.Sprintf("Sources (%d failing)", failing)
+	}
+	sourceTab := tabStyle.Render(sourcesLabel)
 
-	if m.activeTab == 0 {
+	switch m.activeTab {
+	case 0:
 		prTab = activeTabStyle.Render(fmt.Sprintf("Pull Requests (%d)", len(m.pullRequests)))
-	} else {
+	case 1:
 		buildTab = activeTabStyle.Render(fmt.Sprintf("Builds (%d)", len(m.builds)))
+	case 2:
+		sourceTab = activeTabStyle.Render(sourcesLabel)
 	}
 
-	s.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, prTab, "  ", buildTab))
+	s.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, prTab, "  ", buildTab, "  ", sourceTab))
 	s.WriteString("\n\n")
 
 	// Content
-	if m.activeTab == 0 {
+	switch m.activeTab {
+	case 0:
 		s.WriteString(m.prList.View())
-	} else {
+	case 1:
 		s.WriteString(m.buildList.View())
+	case 2:
+		s.WriteString(m.sourceList.View())
 	}
 
 	// Status bar
 	var statusText string
-	if m.activeTab == 0 {
-		statusText = fmt.Sprintf("Last update: %s | Auto-refresh: %v | Press 'r' to refresh, 'tab' to switch, 'enter' to view PR details, 'q' to quit",
+	switch m.activeTab {
+	case 0:
+		statusText = fmt.Sprintf("Last update: %s | Auto-refresh: %v | Press 'r' to refresh, 'tab' to switch, 'f' to filter mine/review, 'enter' to view PR details, 'q' to quit",
 			m.lastUpdate.Format("15:04:05"), m.autoRefresh)
-	} else {
+	case 1:
 		statusText = fmt.Sprintf("Last update: %s | Auto-refresh: %v | Press 'r' to refresh, 'tab' to switch, 'enter' to view build logs, 'q' to quit",
 			m.lastUpdate.Format("15:04:05"), m.autoRefresh)
+	case 2:
+		statusText = fmt.Sprintf("Last update: %s | Auto-refresh: %v | Press 'r' to refresh, 'tab' to switch, 'q' to quit",
+			m.lastUpdate.Format("15:04:05"), m.autoRefresh)
+	}
+	if throttle := m.throttleStatus(); throttle != "" {
+		statusText += " | " + throttle
 	}
 	s.WriteString("\n")
 	s.WriteString(statusStyle.Render(statusText))
 
+	if failing := m.failingSources(); failing > 0 && m.activeTab != 2 {
+		s.WriteString("\n")
+		s.WriteString(errorStyle.Render(fmt.Sprintf("%d of %d sources failed to refresh, see the Sources tab for details",
+			failing, len(m.sourceIDs()))))
+	}
+
 	if m.err != nil {
 		s.WriteString("\n")
 		s.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
@@ -376,6 +1026,25 @@ func (m Model) renderDashboard() string {
 	return s.String()
 }
 
+// throttleStatus describes the API rate limiting state for the status bar
+func (m Model) throttleStatus() string {
+	state := m.client.ThrottleState()
+
+	var parts []string
+	if state.Throttled() {
+		parts = append(parts, fmt.Sprintf("Throttled until %s", state.DelayedUntil.Format("15:04:05")))
+	}
+	if state.Remaining >= 0 {
+		quota := fmt.Sprintf("Quota remaining: %d", state.Remaining)
+		if state.Limit >= 0 {
+			quota = fmt.Sprintf("Quota remaining: %d/%d", state.Remaining, state.Limit)
+		}
+		parts = append(parts, quota)
+	}
+
+	return strings.Join(parts, " | ")
+}
+
 // renderPRFiles renders the PR files view
 func (m Model) renderPRFiles() string {
 	var s strings.Builder
@@ -387,7 +1056,13 @@ func (m Model) renderPRFiles() string {
 
 	s.WriteString(m.fileList.View())
 	s.WriteString("\n")
-	s.WriteString(statusStyle.Render("Press 'enter' to view diff, 'h' or left arrow to go back, 'q' to quit"))
+	if m.editingFileFilter {
+		s.WriteString(m.fileFilterInput.View())
+		s.WriteString("\n")
+		s.WriteString(statusStyle.Render("Press 'enter' to apply (empty clears the filter), 'esc' to cancel"))
+	} else {
+		s.WriteString(statusStyle.Render(m.fileFilterLabel() + "\n" + "Press 'enter' to view diff or collapse a directory, '/' to filter by glob, 'h' or left arrow to go back, 'q' to quit"))
+	}
 
 	if m.err != nil {
 		s.WriteString("\n")
@@ -401,11 +1076,32 @@ func (m Model) renderPRFiles() string {
 func (m Model) renderFileDiff() string {
 	var s strings.Builder
 
-	s.WriteString(titleStyle.Render("File Diff"))
+	s.WriteString(titleStyle.Render(fmt.Sprintf("File Diff: %s", m.currentDiff.NewPath)))
 	s.WriteString("\n\n")
 	s.WriteString(m.diffViewport.View())
 	s.WriteString("\n")
-	s.WriteString(statusStyle.Render("Press 'h' or left arrow to go back, 'q' to quit"))
+	if m.composing {
+		s.WriteString(m.renderCompose())
+	} else if m.searching {
+		s.WriteString(m.searchInput.View() + "  " + statusStyle.Render(m.diffSearch.label()))
+		s.WriteString("\n")
+		s.WriteString(statusStyle.Render("Press 'enter' to keep the search, 'esc' to cancel"))
+	} else {
+		statusText := fmt.Sprintf("Mode: %s | '['/']' prev/next file, '{'/'}' prev/next hunk, '/' search, 'n'/'N' next/previous match, 'C' comment on line, 'R' reply, 'x' resolve, 's' side-by-side, 'f' full file, 'y' copy patch, 'h' or left arrow to go back, 'q' to quit",
+			m.diffModeLabel())
+		if label := m.diffSearch.label(); label != "" {
+			statusText = label + " | " + statusText
+		}
+		if m.notice != "" {
+			statusText = m.notice + " | " + statusText
+		}
+		s.WriteString(statusStyle.Render(statusText))
+	}
+
+	if m.err != nil {
+		s.WriteString("\n")
+		s.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+	}
 
 	return s.String()
 }
@@ -428,6 +1124,40 @@ func (m Model) renderPRDetails() string {
 	s.WriteString(titleStyle.Render(fmt.Sprintf("PR #%d: %s%s", pr.ID, pr.Title, draftIndicator)))
 	s.WriteString("\n\n")
 
+	if m.completion != nil {
+		s.WriteString(m.renderCompletion())
+	} else {
+		s.WriteString(m.prDetailsViewport.View())
+	}
+	s.WriteString("\n")
+	if m.composing {
+		s.WriteString(m.renderCompose())
+	} else if m.prompt != nil {
+		s.WriteString(m.renderPrompt())
+	} else if m.completion == nil {
+		statusText := "Press 'enter' to view files, 'v' to vote, 'm' to complete, 'X' to abandon, 'p' to publish draft, 'b' build logs, '['/']' select comment, 'C' comment, 'R' reply, 'x' resolve, 'g' to open in browser, 'c' to clone repo, 'h' or left arrow to go back, 'q' to quit"
+		if m.notice != "" {
+			statusText = m.notice + " | " + statusText
+		}
+		s.WriteString(statusStyle.Render(statusText))
+	}
+
+	if m.err != nil {
+		s.WriteString("\n")
+		s.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+	}
+
+	return s.String()
+}
+
+// refreshPRDetails renders the details of the selected PR, including its
+// comment threads, into the details viewport
+func (m *Model) refreshPRDetails() {
+	if m.selectedPR == nil {
+		return
+	}
+	pr := m.selectedPR
+
 	// PR Details in a formatted viewport
 	var details strings.Builder
 
@@ -451,7 +1181,14 @@ func (m Model) renderPRDetails() string {
 
 	details.WriteString(lipgloss.NewStyle().Bold(true).Render("Created: "))
 	details.WriteString(pr.CreationDate.Format("2006-01-02 15:04:05"))
-	details.WriteString("\n\n")
+	details.WriteString("\n")
+
+	if reviewer, ok := pr.Reviewer(m.currentUser.ID); ok && m.currentUser.ID != "" {
+		details.WriteString(lipgloss.NewStyle().Bold(true).Render("Your vote: "))
+		details.WriteString(getVoteIcon(reviewer.Vote) + " " + azuredevops.VoteLabel(reviewer.Vote))
+		details.WriteString("\n")
+	}
+	details.WriteString("\n")
 
 	// Branch information
 	sourceBranch := strings.TrimPrefix(pr.SourceRefName, "refs/heads/")
@@ -468,6 +1205,18 @@ func (m Model) renderPRDetails() string {
 	details.WriteString(pr.Repository.Name)
 	details.WriteString("\n\n")
 
+	// Reviewers
+	details.WriteString(lipgloss.NewStyle().Bold(true).Render("Reviewers:"))
+	details.WriteString("\n")
+	details.WriteString(formatReviewers(pr.Reviewers, m.currentUser.ID))
+	details.WriteString("\n")
+
+	// Policies and status checks
+	details.WriteString(lipgloss.NewStyle().Bold(true).Render("Checks:"))
+	details.WriteString("\n")
+	details.WriteString(formatChecks(pr))
+	details.WriteString("\n")
+
 	// Description
 	details.WriteString(lipgloss.NewStyle().Bold(true).Render("Description:"))
 	details.WriteString("\n")
@@ -476,14 +1225,15 @@ func (m Model) renderPRDetails() string {
 	} else {
 		details.WriteString(lipgloss.NewStyle().Italic(true).Foreground(lipgloss.Color("241")).Render("(No description provided)"))
 	}
-	details.WriteString("\n")
+	details.WriteString("\n\n")
 
-	m.prDetailsViewport.SetContent(details.String())
-	s.WriteString(m.prDetailsViewport.View())
-	s.WriteString("\n")
-	s.WriteString(statusStyle.Render("Press 'enter' to view files, 'g' to open in browser, 'c' to clone repo, 'h' or left arrow to go back, 'q' to quit"))
+	// Comment threads
+	threads, offsets := m.formatThreadsSection()
+	m.detailsThreadOffset = strings.Count(details.String(), "\n")
+	m.detailsThreadOffsets = offsets
+	details.WriteString(threads)
 
-	return s.String()
+	m.prDetailsViewport.SetContent(details.String())
 }
 
 // renderBuildLogs renders the build logs view
@@ -492,8 +1242,10 @@ func (m Model) renderBuildLogs() string {
 
 	if m.selectedBuild != nil {
 		s.WriteString(titleStyle.Render(fmt.Sprintf("Build #%s Logs", m.selectedBuild.BuildNumber)))
-		s.WriteString("\n\n")
+		s.WriteString("\n")
 	}
+	s.WriteString(m.logPosition())
+	s.WriteString("\n")
 
 	if m.loadingLogs {
 		s.WriteString("\n  Loading logs...\n")
@@ -501,7 +1253,28 @@ func (m Model) renderBuildLogs() string {
 		s.WriteString(m.logsViewport.View())
 	}
 	s.WriteString("\n")
-	s.WriteString(statusStyle.Render("Press 'g' to open in browser, 'h' or left arrow to go back, 'q' to quit"))
+	if m.searching {
+		s.WriteString(m.searchInput.View() + "  " + statusStyle.Render(m.logSearch.label()))
+		s.WriteString("\n")
+		s.WriteString(statusStyle.Render("Press 'enter' to keep the search, 'esc' to cancel"))
+	} else {
+		statusText := "Press '['/']' for the previous/next log, '/' to search, 'n'/'N' for the next/previous match, 'e' for the first error, 'F' to follow a running build, 'g' to open in browser, 'h' or left arrow to go back, 'q' to quit"
+		if m.following {
+			statusText = "Following | Press 'F' to stop following, '/' to search, 'h' or left arrow to go back, 'q' to quit"
+		}
+		if label := m.logSearch.label(); label != "" {
+			statusText = label + " | " + statusText
+		}
+		if m.notice != "" {
+			statusText = m.notice + " | " + statusText
+		}
+		s.WriteString(statusStyle.Render(statusText))
+	}
+
+	if m.err != nil {
+		s.WriteString("\n")
+		s.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+	}
 
 	return s.String()
 }
@@ -514,33 +1287,37 @@ func (m Model) handleEnter() (Model, tea.Cmd) {
 			// Show PR details
 			idx := m.prList.Index()
 			if idx >= 0 && idx < len(m.pullRequests) {
-				m.selectedPR = &m.pullRequests[idx]
 				m.view = ViewPRDetails
-				return m, nil
+				return m, m.selectPR(&m.pullRequests[idx])
 			}
-		} else if m.activeTab == 1 && len(m.builds) > 0 {
-			// Load build logs
-			idx := m.buildList.Index()
-			if idx >= 0 && idx < len(m.builds) {
-				m.selectedBuild = &m.builds[idx]
-				m.loadingLogs = true
-				return m, m.loadBuildLogs(m.selectedBuild)
+		} else if m.activeTab == 1 {
+			// Show build details
+			if item, ok := m.buildList.SelectedItem().(buildItem); ok {
+				return m, m.openBuild(item)
 			}
 		}
 
+	case ViewBuildDetails:
+		// Show the log of the selected stage, job or task
+		return m, m.openRecordLog()
+
 	case ViewPRDetails:
 		// Navigate to PR files from details view
 		if m.selectedPR != nil {
-			return m, m.loadPRFiles(m.selectedPR)
+			ctx, seq := m.beginViewRequest()
+			return m, m.loadPRFiles(ctx, seq, m.selectedPR)
 		}
 
 	case ViewPRFiles:
 		if len(m.prFiles) > 0 {
 			// Load file diff
-			idx := m.fileList.Index()
-			if idx >= 0 && idx < len(m.prFiles) && m.selectedPR != nil {
-				filePath := m.prFiles[idx]
-				return m, m.loadFileDiff(m.selectedPR, filePath)
+			item, ok := m.fileList.SelectedItem().(fileTreeItem)
+			switch {
+			case !ok:
+			case item.isDir:
+				m.toggleFileDir(item.path)
+			case m.selectedPR != nil:
+				return m, m.openFileDiff(item.fileIndex)
 			}
 		}
 	}
@@ -558,6 +1335,8 @@ func (m *Model) updateSizes() {
 	m.prList.SetSize(m.width-4, listHeight)
 	m.buildList.SetSize(m.width-4, listHeight)
 	m.fileList.SetSize(m.width-4, listHeight)
+	m.sourceList.SetSize(m.width-4, listHeight)
+	m.timelineList.SetSize(m.width-4, listHeight-2)
 	m.diffViewport.Width = m.width - 4
 	m.diffViewport.Height = m.height - 6
 	m.logsViewport.Width = m.width - 4
@@ -580,30 +1359,8 @@ func (m Model) openBuildURL() tea.Cmd {
 			return nil
 		}
 
-		// Try to determine the project by checking which pipeline this build belongs to
-		project := ""
-		if len(m.config.Pipelines) > 0 {
-			// If we only have one project, use it
-			if len(m.config.Pipelines) == 1 {
-				project = m.config.Pipelines[0].Project
-			} else {
-				// Try to match by definition ID
-				for _, p := range m.config.Pipelines {
-					if p.DefinitionID == m.selectedBuild.Definition.ID {
-						project = p.Project
-						break
-					}
-				}
-				// If no match found, use the first project
-				if project == "" {
-					project = m.config.Pipelines[0].Project
-				}
-			}
-		}
-
 		// Construct the Azure DevOps build URL
-		url := fmt.Sprintf("https://dev.azure.com/%s/%s/_build/results?buildId=%d",
-			m.config.Organization, project, m.selectedBuild.ID)
+		url := m.client.URLs().BuildResults(m.selectedBuildProject, m.selectedBuild.ID)
 
 		// Open URL in default browser based on OS
 		var cmd *exec.Cmd
@@ -635,8 +1392,7 @@ func (m Model) openPRURL() tea.Cmd {
 		repository := pr.Repository.Name
 
 		// Construct the Azure DevOps PR URL
-		url := fmt.Sprintf("https://dev.azure.com/%s/%s/_git/%s/pullrequest/%d",
-			m.config.Organization, project, repository, pr.ID)
+		url := m.client.URLs().PullRequest(project, repository, pr.ID)
 
 		// Open URL in default browser based on OS
 		var cmd *exec.Cmd
@@ -668,8 +1424,7 @@ func (m Model) clonePRRepo() tea.Cmd {
 		sourceBranch := strings.TrimPrefix(pr.SourceRefName, "refs/heads/")
 
 		// Construct the clone URL
-		cloneURL := fmt.Sprintf("https://dev.azure.com/%s/%s/_git/%s",
-			m.config.Organization, pr.Repository.Project.Name, repository)
+		cloneURL := m.client.URLs().Repository(pr.Repository.Project.Name, repository)
 
 		// Clone to current directory with repository name
 		cloneCmd := exec.Command("git", "clone", cloneURL, repository)
@@ -685,46 +1440,66 @@ func (m Model) clonePRRepo() tea.Cmd {
 	}
 }
 
-// formatDiff colorizes diff output with Lipgloss
-func (m Model) formatDiff(diff string) string {
-	lines := strings.Split(diff, "\n")
-	var result strings.Builder
+// formatDiff colorizes a file diff with Lipgloss, with old and new line numbers in a gutter.
+// Syntax highlighting is layered underneath the added and deleted line backgrounds.
+func (m Model) formatDiff(diff azuredevops.FileDiff) []diffRow {
+	var rows []diffRow
 
 	// Define styles for different diff elements
-	addedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("46"))        // bright green
-	deletedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))     // bright red
+	addedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("46"))             // bright green
+	deletedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))          // bright red
 	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true) // bright blue
-	hunkStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("141"))        // purple/magenta
-
-	for _, line := range lines {
-		if len(line) == 0 {
-			result.WriteString("\n")
-			continue
-		}
-
-		switch {
-		case strings.HasPrefix(line, "+"):
-			if !strings.HasPrefix(line, "+++") {
-				result.WriteString(addedStyle.Render(line) + "\n")
-			} else {
-				result.WriteString(headerStyle.Render(line) + "\n")
-			}
-		case strings.HasPrefix(line, "-"):
-			if !strings.HasPrefix(line, "---") {
-				result.WriteString(deletedStyle.Render(line) + "\n")
-			} else {
-				result.WriteString(headerStyle.Render(line) + "\n")
-			}
-		case strings.HasPrefix(line, "@@"):
-			result.WriteString(hunkStyle.Render(line) + "\n")
-		case strings.HasPrefix(line, "diff --git"):
-			result.WriteString(headerStyle.Render(line) + "\n")
-		case strings.HasPrefix(line, "new file") || strings.HasPrefix(line, "deleted file"):
-			result.WriteString(hunkStyle.Render(line) + "\n")
-		default:
-			result.WriteString(line + "\n")
+	hunkStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("141"))             // purple/magenta
+	gutterStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))           // gray
+
+	// Everything before the first hunk header is the file header
+	patch := diff.Unified()
+	for _, line := range strings.Split(patch, "\n") {
+		if strings.HasPrefix(line, "@@") || line == "" {
+			break
+		}
+		rows = append(rows, diffRow{text: headerStyle.Render(line)})
+	}
+
+	if diff.IsBinary {
+		return rows
+	}
+	if len(diff.Hunks) == 0 {
+		return append(rows, diffRow{text: hunkStyle.Render("No content changes")})
+	}
+
+	for _, hunk := range diff.Hunks {
+		rows = append(rows, diffRow{text: hunkStyle.Render(hunk.Header()), hunk: true})
+		for _, line := range hunk.Lines {
+			text := gutterStyle.Render(formatLineNumbers(line))
+			switch line.Kind {
+			case azuredevops.DiffAdded:
+				text += addedStyle.Copy().Background(addedBackground).Render("+")
+				text += renderCode(m.diffHighlight.newLines, line.NewLine, line.Text, addedBackground)
+			case azuredevops.DiffDeleted:
+				text += deletedStyle.Copy().Background(deletedBackground).Render("-")
+				text += renderCode(m.diffHighlight.oldLines, line.OldLine, line.Text, deletedBackground)
+			default:
+				text += " " + renderCode(m.diffHighlight.newLines, line.NewLine, line.Text, nil)
+			}
+			rows = append(rows, diffRow{text: text, oldLine: line.OldLine, newLine: line.NewLine})
+			if line.NoNewline {
+				rows = append(rows, diffRow{text: gutterStyle.Render(strings.Repeat(" ", 14) + "\\ No newline at end of file")})
+			}
 		}
 	}
 
-	return result.String()
+	return rows
+}
+
+// formatLineNumbers renders the old and new line number gutter for a diff line
+func formatLineNumbers(line azuredevops.DiffLine) string {
+	oldNumber, newNumber := "", ""
+	if line.OldLine > 0 {
+		oldNumber = fmt.Sprintf("%d", line.OldLine)
+	}
+	if line.NewLine > 0 {
+		newNumber = fmt.Sprintf("%d", line.NewLine)
+	}
+	return fmt.Sprintf("%5s %5s │ ", oldNumber, newNumber)
 }
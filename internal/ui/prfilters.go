@@ -0,0 +1,206 @@
+package ui
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/azuredevops"
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/config"
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/glob"
+)
+
+// prView selects which of the loaded pull requests the dashboard shows
+type prView int
+
+const (
+	prViewAll prView = iota
+	prViewMine
+	prViewNeedsReview
+	prViewVoteReset
+	prViewCount
+)
+
+// label returns the name of the PR view shown in the list title
+func (v prView) label() string {
+	switch v {
+	case prViewMine:
+		return "My PRs"
+	case prViewNeedsReview:
+		return "Needs my review"
+	case prViewVoteReset:
+		return "My vote was reset"
+	}
+	return "Pull Requests"
+}
+
+// includes reports whether a pull request belongs in the view for the given user
+func (v prView) includes(pr azuredevops.PullRequest, userID string) bool {
+	if v == prViewAll {
+		return true
+	}
+	if userID == "" {
+		return false // Unknown until the identity has been looked up
+	}
+
+	switch v {
+	case prViewMine:
+		return pr.CreatedBy.ID == userID
+	case prViewNeedsReview:
+		reviewer, ok := pr.Reviewer(userID)
+		return ok && reviewer.Vote == azuredevops.VoteNone && !reviewer.HasDeclined && pr.CreatedBy.ID != userID
+	case prViewVoteReset:
+		return pr.VoteReset
+	}
+	return false
+}
+
+// cyclePRView switches the dashboard to the next PR view
+func (m *Model) cyclePRView() {
+	m.prView = (m.prView + 1) % prViewCount
+	m.updateLists()
+	m.prList.ResetSelected()
+}
+
+// prListTitle returns the title of the PR list for the current view
+func (m Model) prListTitle() string {
+	title := m.prView.label()
+	if m.prView != prViewAll && m.currentUser.ID == "" {
+		title += " (looking up who you are...)"
+	}
+	return title
+}
+
+// guidPattern matches Azure DevOps identity IDs
+var guidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// sourceFilter narrows down the PRs of a pull request source. What the API
+// can filter on is sent as search criteria, the rest is matched here.
+type sourceFilter struct {
+	criteria       azuredevops.PRSearchCriteria
+	repository     *glob.Pattern   // nil for every repository
+	targetBranches []*glob.Pattern // Matched against the branch name without refs/heads/
+	createdBy      string          // Matched by name when not sent as CreatorID
+	reviewer       string          // Matched by name when not sent as ReviewerID
+	labels         []string
+	drafts         string
+}
+
+// newSourceFilter builds the filter of a pull request source, resolving
+// "@me" to the authenticated user
+func (m Model) newSourceFilter(ctx context.Context, prConfig config.PullRequestConfig) (sourceFilter, error) {
+	filter := sourceFilter{
+		labels: prConfig.Labels,
+		drafts: prConfig.Drafts,
+	}
+
+	// Patterns were validated when the config was loaded
+	if prConfig.Repository != "" {
+		filter.repository, _ = glob.CompileIgnoreCase(prConfig.Repository)
+	}
+	for _, branch := range prConfig.TargetBranches {
+		branch = strings.TrimPrefix(branch, "refs/heads/")
+		if len(prConfig.TargetBranches) == 1 && glob.IsLiteral(branch) {
+			filter.criteria.TargetRefName = "refs/heads/" + branch
+			break
+		}
+		pattern, _ := glob.CompileName(branch)
+		filter.targetBranches = append(filter.targetBranches, pattern)
+	}
+
+	resolve := func(user string) (id, name string, err error) {
+		switch {
+		case user == "@me":
+			identity, err := m.client.CurrentUser(ctx)
+			if err != nil {
+				return "", "", err
+			}
+			return identity.ID, "", nil
+		case guidPattern.MatchString(user):
+			return user, "", nil
+		}
+		return "", user, nil
+	}
+
+	var err error
+	if filter.criteria.CreatorID, filter.createdBy, err = resolve(prConfig.CreatedBy); err != nil {
+		return sourceFilter{}, err
+	}
+	if filter.criteria.ReviewerID, filter.reviewer, err = resolve(prConfig.Reviewer); err != nil {
+		return sourceFilter{}, err
+	}
+
+	return filter, nil
+}
+
+// clientSide reports whether the filter drops PRs the server returns, so the
+// server can't be trusted to apply the source's limit
+func (f sourceFilter) clientSide() bool {
+	return f.repository != nil || len(f.targetBranches) > 0 || f.createdBy != "" || f.reviewer != "" ||
+		len(f.labels) > 0 || (f.drafts != "" && f.drafts != config.DraftsInclude)
+}
+
+// matches reports whether a PR returned by the server passes the client side filters
+func (f sourceFilter) matches(pr azuredevops.PullRequest) bool {
+	if f.repository != nil && !f.repository.Match(pr.Repository.Name) {
+		return false
+	}
+
+	if len(f.targetBranches) > 0 {
+		branch := strings.TrimPrefix(pr.TargetRefName, "refs/heads/")
+		matched := false
+		for _, pattern := range f.targetBranches {
+			matched = matched || pattern.Match(branch)
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if f.createdBy != "" && !userMatches(f.createdBy, pr.CreatedBy.ID, pr.CreatedBy.UniqueName, pr.CreatedBy.DisplayName) {
+		return false
+	}
+
+	if f.reviewer != "" {
+		matched := false
+		for _, reviewer := range pr.Reviewers {
+			matched = matched || userMatches(f.reviewer, reviewer.ID, reviewer.UniqueName, reviewer.DisplayName)
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(f.labels) > 0 {
+		matched := false
+		for _, label := range pr.Labels {
+			for _, wanted := range f.labels {
+				matched = matched || (label.Active && strings.EqualFold(label.Name, wanted))
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	switch f.drafts {
+	case config.DraftsExclude:
+		return !pr.IsDraft
+	case config.DraftsOnly:
+		return pr.IsDraft
+	}
+	return true
+}
+
+// userMatches reports whether a configured user, given by email, display name
+// or team name, is the user with the given details. Team reviewers have
+// display names like "[Project]\Team", which also match by team name alone.
+func userMatches(want, id, uniqueName, displayName string) bool {
+	if strings.EqualFold(want, id) || strings.EqualFold(want, uniqueName) || strings.EqualFold(want, displayName) {
+		return true
+	}
+	if i := strings.LastIndex(displayName, `\`); i >= 0 {
+		return strings.EqualFold(want, displayName[i+1:])
+	}
+	return false
+}
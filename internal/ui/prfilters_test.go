@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/azuredevops"
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/config"
+)
+
+func TestSourceFilterMatches(t *testing.T) {
+	pr := azuredevops.PullRequest{
+		Repository:    azuredevops.Repository{Name: "Web-Frontend"},
+		TargetRefName: "refs/heads/release/1.0",
+		CreatedBy:     azuredevops.User{ID: "1", UniqueName: "ada@example.com", DisplayName: "Ada Lovelace"},
+		Reviewers: []azuredevops.Reviewer{
+			{ID: "2", UniqueName: "grace@example.com", DisplayName: "Grace Hopper"},
+			{ID: "3", DisplayName: `[Project]\Platform Team`, IsContainer: true},
+		},
+		Labels:  []azuredevops.Label{{Name: "Urgent", Active: true}, {Name: "stale", Active: false}},
+		IsDraft: true,
+	}
+
+	tests := []struct {
+		name   string
+		config config.PullRequestConfig
+		want   bool
+	}{
+		{name: "no filters", want: true},
+		{name: "repository glob ignores case", config: config.PullRequestConfig{Repository: "web-*"}, want: true},
+		{name: "other repository", config: config.PullRequestConfig{Repository: "api-*"}, want: false},
+		{name: "target branch glob", config: config.PullRequestConfig{TargetBranches: []string{"main", "release/*"}}, want: true},
+		{name: "target branch with prefix", config: config.PullRequestConfig{TargetBranches: []string{"refs/heads/release/*", "main"}}, want: true},
+		{name: "other target branches", config: config.PullRequestConfig{TargetBranches: []string{"main", "develop"}}, want: false},
+		{name: "author by email", config: config.PullRequestConfig{CreatedBy: "ADA@example.com"}, want: true},
+		{name: "author by display name", config: config.PullRequestConfig{CreatedBy: "Ada Lovelace"}, want: true},
+		{name: "other author", config: config.PullRequestConfig{CreatedBy: "grace@example.com"}, want: false},
+		{name: "reviewer", config: config.PullRequestConfig{Reviewer: "Grace Hopper"}, want: true},
+		{name: "team reviewer by team name", config: config.PullRequestConfig{Reviewer: "platform team"}, want: true},
+		{name: "other reviewer", config: config.PullRequestConfig{Reviewer: "Ada Lovelace"}, want: false},
+		{name: "active label ignores case", config: config.PullRequestConfig{Labels: []string{"urgent"}}, want: true},
+		{name: "inactive label", config: config.PullRequestConfig{Labels: []string{"stale"}}, want: false},
+		{name: "drafts included", config: config.PullRequestConfig{Drafts: config.DraftsInclude}, want: true},
+		{name: "drafts excluded", config: config.PullRequestConfig{Drafts: config.DraftsExclude}, want: false},
+		{name: "drafts only", config: config.PullRequestConfig{Drafts: config.DraftsOnly}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := Model{}.newSourceFilter(context.Background(), tt.config)
+			if err != nil {
+				t.Fatalf("newSourceFilter() error = %v", err)
+			}
+			if got := filter.matches(pr); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSourceFilterCriteria(t *testing.T) {
+	const id = "6f8c4a2e-1b3d-4c5e-9f7a-0b1c2d3e4f5a"
+	filter, err := Model{}.newSourceFilter(context.Background(), config.PullRequestConfig{
+		TargetBranches: []string{"main"},
+		CreatedBy:      id,
+	})
+	if err != nil {
+		t.Fatalf("newSourceFilter() error = %v", err)
+	}
+
+	// A single literal branch and a user ID are left to the server
+	if filter.criteria.TargetRefName != "refs/heads/main" || filter.criteria.CreatorID != id {
+		t.Errorf("criteria = %+v, want target refs/heads/main and creator %s", filter.criteria, id)
+	}
+	if filter.clientSide() {
+		t.Errorf("clientSide() = true, want false when the server applies every filter")
+	}
+}
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// promptOption is an answer to a prompt, chosen by pressing its key
+type promptOption struct {
+	key    string
+	label  string
+	action func(m *Model) tea.Cmd
+}
+
+// prompt is a question shown in place of the status bar that is answered
+// with a single key, used to confirm actions that change data in Azure DevOps
+type prompt struct {
+	question string
+	options  []promptOption
+}
+
+// ask shows a prompt with the given options. Esc always dismisses it.
+func (m *Model) ask(question string, options ...promptOption) {
+	m.prompt = &prompt{question: question, options: options}
+}
+
+// confirm asks a yes/no question and runs action when the answer is yes
+func (m *Model) confirm(question string, action tea.Cmd) {
+	m.ask(question,
+		promptOption{key: "y", label: "yes", action: func(m *Model) tea.Cmd { return action }},
+		promptOption{key: "n", label: "no", action: func(m *Model) tea.Cmd { return nil }},
+	)
+}
+
+// updatePrompt handles keys while a prompt is shown
+func (m Model) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "esc" {
+		m.prompt = nil
+		return m, nil
+	}
+
+	for _, option := range m.prompt.options {
+		if msg.String() == option.key {
+			m.prompt = nil
+			return m, option.action(&m)
+		}
+	}
+
+	return m, nil
+}
+
+// renderPrompt renders the current prompt in place of a view's status bar
+func (m Model) renderPrompt() string {
+	keyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true)
+
+	answers := make([]string, 0, len(m.prompt.options)+1)
+	for _, option := range m.prompt.options {
+		answers = append(answers, fmt.Sprintf("%s %s", keyStyle.Render(option.key), option.label))
+	}
+	answers = append(answers, fmt.Sprintf("%s cancel", keyStyle.Render("esc")))
+
+	return lipgloss.NewStyle().Bold(true).Render(m.prompt.question) + "\n" +
+		statusStyle.Render(strings.Join(answers, "  "))
+}
@@ -0,0 +1,357 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// errorMarker starts the lines of Azure Pipelines logs that report an error
+const errorMarker = "##[error]"
+
+// Highlights of search matches, written as raw SGR sequences because the
+// lines they are spliced into are already styled
+const (
+	matchHighlight        = "\x1b[7m"      // Reverse video
+	currentMatchHighlight = "\x1b[30;103m" // Black on bright yellow
+	resetStyle            = "\x1b[0m"
+)
+
+// searchMatch is a match of a search, as byte offsets into the unstyled text
+// of a line
+type searchMatch struct {
+	line       int
+	start, end int
+}
+
+// textSearch is a regular expression search through the lines of a viewport
+type textSearch struct {
+	query   string
+	pattern *regexp.Regexp // nil when there is no search
+	err     error          // Why the query isn't a valid regular expression
+	matches []searchMatch
+	current int // Index into matches of the match shown, -1 when none
+}
+
+// setQuery replaces the search. A query without upper case letters matches
+// case-insensitively.
+func (s *textSearch) setQuery(query string) {
+	*s = textSearch{query: query, current: -1}
+	if query == "" {
+		return
+	}
+
+	expr := query
+	if !strings.ContainsFunc(query, unicode.IsUpper) {
+		expr = "(?i)" + query
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		s.err = err
+		return
+	}
+	s.pattern = pattern
+}
+
+// find finds the matches of the search in the given lines, keeping the match
+// shown when there still is one
+func (s *textSearch) find(lines []string) {
+	s.matches = nil
+	if s.pattern != nil {
+		for i, line := range lines {
+			for _, loc := range s.pattern.FindAllStringIndex(stripANSI(line), -1) {
+				if loc[0] < loc[1] {
+					s.matches = append(s.matches, searchMatch{line: i, start: loc[0], end: loc[1]})
+				}
+			}
+		}
+	}
+	if s.current >= len(s.matches) {
+		s.current = len(s.matches) - 1
+	}
+}
+
+// seek selects the first match at or after a line, wrapping around to the
+// first match, and returns its line
+func (s *textSearch) seek(line int) (int, bool) {
+	if len(s.matches) == 0 {
+		return 0, false
+	}
+	s.current = 0
+	for i, match := range s.matches {
+		if match.line >= line {
+			s.current = i
+			break
+		}
+	}
+	return s.matches[s.current].line, true
+}
+
+// step selects the next (direction 1) or previous (direction -1) match,
+// wrapping around at either end, and returns its line
+func (s *textSearch) step(direction int) (int, bool) {
+	if len(s.matches) == 0 {
+		return 0, false
+	}
+	switch {
+	case s.current < 0 && direction > 0:
+		s.current = 0
+	case s.current < 0:
+		s.current = len(s.matches) - 1
+	default:
+		s.current = (s.current + direction + len(s.matches)) % len(s.matches)
+	}
+	return s.matches[s.current].line, true
+}
+
+// highlight returns the lines with the matches of the search highlighted
+func (s textSearch) highlight(lines []string) []string {
+	if len(s.matches) == 0 {
+		return lines
+	}
+
+	highlighted := make([]string, len(lines))
+	copy(highlighted, lines)
+	for first := 0; first < len(s.matches); {
+		line := s.matches[first].line
+		last := first
+		for last < len(s.matches) && s.matches[last].line == line {
+			last++
+		}
+		highlighted[line] = highlightMatches(lines[line], s.matches[first:last], s.current-first)
+		first = last
+	}
+	return highlighted
+}
+
+// label describes the search for the status bar
+func (s textSearch) label() string {
+	switch {
+	case s.query == "":
+		return ""
+	case s.err != nil:
+		return fmt.Sprintf("/%s: invalid pattern", s.query)
+	case len(s.matches) == 0:
+		return fmt.Sprintf("/%s: no matches", s.query)
+	case s.current < 0:
+		return fmt.Sprintf("/%s: %d matches", s.query, len(s.matches))
+	}
+	return fmt.Sprintf("/%s: match %d of %d", s.query, s.current+1, len(s.matches))
+}
+
+// highlightMatches highlights the matches of one line, the match at index
+// current differently. Styles of the line are re-applied after each match.
+func highlightMatches(line string, matches []searchMatch, current int) string {
+	var b strings.Builder
+	var active string // Styles set since the last reset
+	var highlight string
+	inMatch := false
+	index, offset := 0, 0 // offset counts the unstyled bytes written
+
+	end := func() {
+		b.WriteString(resetStyle + active)
+		inMatch = false
+		index++
+	}
+
+	for i := 0; i < len(line); {
+		if n := ansiSequenceLength(line, i); n > 0 {
+			sequence := line[i : i+n]
+			b.WriteString(sequence)
+			if sequence == resetStyle || sequence == "\x1b[m" {
+				active = ""
+			} else {
+				active += sequence
+			}
+			if inMatch {
+				b.WriteString(highlight) // The line's style may have reset it
+			}
+			i += n
+			continue
+		}
+
+		if inMatch && offset == matches[index].end {
+			end()
+		}
+		if !inMatch && index < len(matches) && offset == matches[index].start {
+			highlight = matchHighlight
+			if index == current {
+				highlight = currentMatchHighlight
+			}
+			b.WriteString(highlight)
+			inMatch = true
+		}
+		b.WriteByte(line[i])
+		i++
+		offset++
+	}
+	if inMatch {
+		end()
+	}
+	return b.String()
+}
+
+// ansiSequenceLength returns the length of the ANSI escape sequence starting
+// at s[i], or 0 if none starts there
+func ansiSequenceLength(s string, i int) int {
+	if s[i] != '\x1b' || i+1 >= len(s) {
+		return 0
+	}
+	if s[i+1] != '[' {
+		return 2
+	}
+	for j := i + 2; j < len(s); j++ {
+		if s[j] >= 0x40 && s[j] <= 0x7e {
+			return j - i + 1
+		}
+	}
+	return len(s) - i
+}
+
+// stripANSI removes the ANSI escape sequences from a string
+func stripANSI(s string) string {
+	if !strings.Contains(s, "\x1b") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if n := ansiSequenceLength(s, i); n > 0 {
+			i += n
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// scrollToLine scrolls a viewport so a line is in view, near the middle of
+// the viewport when it has to scroll
+func scrollToLine(v *viewport.Model, line int) {
+	if line >= v.YOffset && line < v.YOffset+v.Height {
+		return
+	}
+	v.SetYOffset(max(line-v.Height/2, 0))
+}
+
+// activeSearch returns the search of the current view, nil if it has none
+func (m *Model) activeSearch() *textSearch {
+	switch m.view {
+	case ViewBuildLogs:
+		return &m.logSearch
+	case ViewFileDiff:
+		return &m.diffSearch
+	}
+	return nil
+}
+
+// startSearch opens the search prompt of the logs or diff view
+func (m *Model) startSearch() tea.Cmd {
+	search := m.activeSearch()
+	if search == nil {
+		return nil
+	}
+	m.searching = true
+	m.searchPrevious = search.query
+	m.searchOrigin = m.logsViewport.YOffset
+	if m.view == ViewFileDiff {
+		m.searchOrigin = m.diffCursor
+	}
+	m.searchInput.SetValue("")
+	return m.searchInput.Focus()
+}
+
+// updateSearch handles keys while the search prompt is open, searching as
+// the query is typed
+func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		m.searching = false
+		m.searchInput.Blur()
+		return m, nil
+
+	case "esc":
+		// Put back the previous search and position
+		m.searching = false
+		m.searchInput.Blur()
+		m.applySearch(m.searchPrevious)
+		if m.view == ViewFileDiff {
+			m.moveDiffCursor(m.searchOrigin)
+		} else {
+			m.logsViewport.SetYOffset(m.searchOrigin)
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	m.applySearch(m.searchInput.Value())
+	return m, cmd
+}
+
+// applySearch searches the current view for a query and shows the first
+// match from where the search started
+func (m *Model) applySearch(query string) {
+	search := m.activeSearch()
+	if search == nil {
+		return
+	}
+	search.setQuery(query)
+	if m.view == ViewFileDiff {
+		m.refreshDiffView()
+		if line, ok := search.seek(m.searchOrigin); ok {
+			m.moveDiffCursor(line)
+		}
+		return
+	}
+	m.renderLogs()
+	if line, ok := search.seek(m.searchOrigin); ok {
+		m.renderLogs()
+		scrollToLine(&m.logsViewport, line)
+	}
+}
+
+// stepSearch shows the next (direction 1) or previous (direction -1) match
+// of the search of the current view
+func (m *Model) stepSearch(direction int) {
+	search := m.activeSearch()
+	if search == nil || search.query == "" {
+		return
+	}
+	line, ok := search.step(direction)
+	if !ok {
+		return
+	}
+	if m.view == ViewFileDiff {
+		m.moveDiffCursor(line)
+		return
+	}
+	m.renderLogs()
+	scrollToLine(&m.logsViewport, line)
+}
+
+// jumpToFirstError scrolls the log to its first loaded error line
+func (m *Model) jumpToFirstError() {
+	for i, line := range strings.Split(m.buildLogs, "\n") {
+		if strings.Contains(stripANSI(line), errorMarker) {
+			m.logsViewport.SetYOffset(i)
+			return
+		}
+	}
+	m.notice = "No errors in the loaded lines"
+	if m.logFirstLine > 1 {
+		m.notice += ", press 'u' to load earlier lines"
+	}
+}
+
+// renderLogs sets the logs viewport content to the loaded log lines with the
+// matches of the search highlighted
+func (m *Model) renderLogs() {
+	lines := strings.Split(m.buildLogs, "\n")
+	m.logSearch.find(lines)
+	m.logsViewport.SetContent(strings.Join(m.logSearch.highlight(lines), "\n"))
+}
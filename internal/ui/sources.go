@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// sourceStatus tracks the health of a configured source across refreshes
+type sourceStatus struct {
+	loaded      bool      // At least one refresh has completed for the source
+	lastSuccess time.Time // When the source last loaded successfully
+	lastErr     error     // Error of the latest refresh, nil if it succeeded
+	lastErrTime time.Time // When lastErr occurred
+	partialErr  error     // Why part of the last successful refresh failed, e.g. some projects
+}
+
+// stale reports whether the data shown for the source is left over from an
+// earlier refresh because the latest one failed
+func (s sourceStatus) stale() bool {
+	return s.lastErr != nil && !s.lastSuccess.IsZero()
+}
+
+// sourceItem wraps a configured source and its status for use in a list
+type sourceItem struct {
+	name   string
+	kind   sourceKind
+	status sourceStatus
+}
+
+func (i sourceItem) FilterValue() string {
+	return i.name
+}
+
+func (i sourceItem) Title() string {
+	kind := "Repository"
+	if i.kind == sourcePipeline {
+		kind = "Pipeline"
+	}
+
+	return fmt.Sprintf("%s %s: %s", getSourceHealthIcon(i.status), kind, i.name)
+}
+
+func (i sourceItem) Description() string {
+	lastSuccess := "never"
+	if !i.status.lastSuccess.IsZero() {
+		lastSuccess = i.status.lastSuccess.Format("2006-01-02 15:04:05")
+	}
+
+	if !i.status.loaded {
+		return "Loading..."
+	}
+
+	if i.status.lastErr != nil {
+		return fmt.Sprintf("Last success: %s | Failed at %s: %v",
+			lastSuccess,
+			i.status.lastErrTime.Format("15:04:05"),
+			i.status.lastErr)
+	}
+
+	if i.status.partialErr != nil {
+		return fmt.Sprintf("Last success: %s | Partly failed: %v", lastSuccess, i.status.partialErr)
+	}
+
+	return fmt.Sprintf("Last success: %s", lastSuccess)
+}
+
+// getSourceHealthIcon returns a colored icon for a source's health
+func getSourceHealthIcon(status sourceStatus) string {
+	switch {
+	case !status.loaded:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("◯") // Gray
+	case status.lastErr == nil && status.partialErr == nil:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render("✓") // Green
+	case status.stale(), status.lastErr == nil:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render("◐") // Yellow
+	default:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render("✗") // Red
+	}
+}
+
+// failingSources returns the number of sources whose latest refresh failed
+func (m Model) failingSources() int {
+	failing := 0
+	for _, status := range m.sourceStatuses {
+		if status.lastErr != nil {
+			failing++
+		}
+	}
+	return failing
+}
+
+// updateSourceList updates the sources list with the current source health
+func (m *Model) updateSourceList() {
+	ids := m.sourceIDs()
+	sourceItems := make([]list.Item, len(ids))
+	for i, id := range ids {
+		sourceItems[i] = sourceItem{
+			name:   m.sourceName(id),
+			kind:   id.kind,
+			status: m.sourceStatuses[id],
+		}
+	}
+	m.sourceList.SetItems(sourceItems)
+}
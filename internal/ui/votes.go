@@ -0,0 +1,196 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ulve/azuredevops-terminal-dashboard/internal/azuredevops"
+)
+
+// loadIdentity looks up the user the PAT belongs to
+func (m Model) loadIdentity() tea.Cmd {
+	return func() tea.Msg {
+		identity, err := m.client.CurrentUser(m.ctx)
+		return IdentityLoadedMsg{identity: identity, err: err}
+	}
+}
+
+// startVote asks which vote to cast on the selected PR, then asks for
+// confirmation before casting it
+func (m *Model) startVote() {
+	pr := m.selectedPR
+	option := func(key string, vote int) promptOption {
+		label := azuredevops.VoteLabel(vote)
+		if vote == azuredevops.VoteNone {
+			label = "Reset vote"
+		}
+		return promptOption{
+			key:   key,
+			label: label,
+			action: func(m *Model) tea.Cmd {
+				m.confirm(fmt.Sprintf("Set your vote on PR #%d to '%s'?", pr.ID, label), m.castVote(pr, vote))
+				return nil
+			},
+		}
+	}
+
+	m.ask(fmt.Sprintf("Vote on PR #%d:", pr.ID),
+		option("a", azuredevops.VoteApproved),
+		option("s", azuredevops.VoteApprovedWithSuggestions),
+		option("w", azuredevops.VoteWaitingForAuthor),
+		option("r", azuredevops.VoteRejected),
+		option("0", azuredevops.VoteNone),
+	)
+}
+
+// castVote sets the authenticated user's vote on a pull request. The vote
+// isn't canceled by leaving the view, only by quitting.
+func (m Model) castVote(pr *azuredevops.PullRequest, vote int) tea.Cmd {
+	return func() tea.Msg {
+		identity, err := m.client.CurrentUser(m.ctx)
+		if err != nil {
+			return VoteCastMsg{prID: pr.ID, repositoryName: pr.Repository.Name, err: err}
+		}
+
+		reviewer, err := m.client.SetVote(m.ctx, pr.Repository.Project.Name, pr.Repository.Name, pr.ID, identity.ID, vote)
+		return VoteCastMsg{prID: pr.ID, repositoryName: pr.Repository.Name, reviewer: reviewer, err: err}
+	}
+}
+
+// applyVote records a cast vote on the PR in every list showing it
+func (m *Model) applyVote(msg VoteCastMsg) {
+	m.updatePR(msg.repositoryName, msg.prID, func(pr *azuredevops.PullRequest) {
+		pr.VoteReset = false
+		for i := range pr.Reviewers {
+			if pr.Reviewers[i].ID == msg.reviewer.ID {
+				pr.Reviewers[i].Vote = msg.reviewer.Vote
+				return
+			}
+		}
+		pr.Reviewers = append(pr.Reviewers, msg.reviewer)
+	})
+	m.notice = fmt.Sprintf("Voted '%s' on PR #%d", azuredevops.VoteLabel(msg.reviewer.Vote), msg.prID)
+}
+
+// updatePR applies a change to a loaded pull request and refreshes the lists
+// and details that show it, so changes made from the dashboard show up before
+// the next refresh
+func (m *Model) updatePR(repository string, prID int, change func(pr *azuredevops.PullRequest)) {
+	for index, prs := range m.sourcePRs {
+		updated := make([]azuredevops.PullRequest, len(prs))
+		copy(updated, prs)
+		for i := range updated {
+			if updated[i].ID == prID && updated[i].Repository.Name == repository {
+				change(&updated[i])
+			}
+		}
+		m.sourcePRs[index] = updated
+	}
+	m.updateLists()
+
+	if m.selectedPR != nil && m.selectedPR.ID == prID && m.selectedPR.Repository.Name == repository {
+		selected := *m.selectedPR
+		change(&selected)
+		m.selectedPR = &selected
+		m.refreshPRDetails()
+	}
+}
+
+// getVoteIcon returns a colored icon for a reviewer vote
+func getVoteIcon(vote int) string {
+	switch vote {
+	case azuredevops.VoteApproved:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render("✓") // Green
+	case azuredevops.VoteApprovedWithSuggestions:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render("✓~") // Green
+	case azuredevops.VoteWaitingForAuthor:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render("⏸") // Yellow
+	case azuredevops.VoteRejected:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render("✗") // Red
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("○") // Gray
+}
+
+// formatVoteSummary returns a compact count of a PR's votes, e.g. "✓2 ⏸1 ○3",
+// or an empty string when the PR has no reviewers
+func formatVoteSummary(summary azuredevops.VoteSummary) string {
+	var parts []string
+	counts := []struct {
+		vote  int
+		count int
+	}{
+		{azuredevops.VoteApproved, summary.Approved},
+		{azuredevops.VoteWaitingForAuthor, summary.WaitingForAuthor},
+		{azuredevops.VoteRejected, summary.Rejected},
+		{azuredevops.VoteNone, summary.NoVote},
+	}
+	for _, c := range counts {
+		if c.count > 0 {
+			parts = append(parts, fmt.Sprintf("%s%d", getVoteIcon(c.vote), c.count))
+		}
+	}
+	if summary.RequiredPending > 0 {
+		parts = append(parts, lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render(fmt.Sprintf("%d required pending", summary.RequiredPending)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatReviewers renders the reviewers of a PR as a table, required
+// reviewers first
+func formatReviewers(reviewers []azuredevops.Reviewer, userID string) string {
+	if len(reviewers) == 0 {
+		return lipgloss.NewStyle().Italic(true).Foreground(lipgloss.Color("241")).Render("(No reviewers)") + "\n"
+	}
+
+	sorted := make([]azuredevops.Reviewer, len(reviewers))
+	copy(sorted, reviewers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].IsRequired && !sorted[j].IsRequired
+	})
+
+	nameWidth := 0
+	for _, reviewer := range sorted {
+		nameWidth = max(nameWidth, lipgloss.Width(reviewer.DisplayName))
+	}
+
+	flagStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	var s strings.Builder
+	for _, reviewer := range sorted {
+		var flags []string
+		if reviewer.IsRequired {
+			flags = append(flags, "required")
+		}
+		if reviewer.IsContainer {
+			flags = append(flags, "group")
+		}
+		if reviewer.HasDeclined {
+			flags = append(flags, "declined")
+		}
+		if reviewer.ID == userID && userID != "" {
+			flags = append(flags, "you")
+		}
+		if len(reviewer.VotedFor) > 0 {
+			names := make([]string, len(reviewer.VotedFor))
+			for i, votedFor := range reviewer.VotedFor {
+				names[i] = votedFor.DisplayName
+			}
+			label := "for "
+			if reviewer.IsContainer {
+				label = "by "
+			}
+			flags = append(flags, label+strings.Join(names, ", "))
+		}
+
+		icon := getVoteIcon(reviewer.Vote)
+		icon += strings.Repeat(" ", 2-lipgloss.Width(icon))
+		line := fmt.Sprintf("%s %-*s  %-25s", icon, nameWidth, reviewer.DisplayName, azuredevops.VoteLabel(reviewer.Vote))
+		if len(flags) > 0 {
+			line += " " + flagStyle.Render(strings.Join(flags, ", "))
+		}
+		s.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	return s.String()
+}